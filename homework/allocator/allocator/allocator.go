@@ -0,0 +1,118 @@
+package allocator
+
+import (
+	"errors"
+	"unsafe"
+)
+
+var (
+	ErrInvalidCapacity  = errors.New("incorrect capacity")
+	ErrInvalidSize      = errors.New("incorrect size")
+	ErrInvalidAlignment = errors.New("alignment must be a power of two")
+	ErrOutOfMemory      = errors.New("not enough memory")
+	ErrInvalidPointer   = errors.New("incorrect pointer")
+	ErrNotSupported     = errors.New("not supported by this kind of allocator")
+)
+
+// Allocator hands out memory of a single []byte buffer, it isn't scanned
+// by the garbage collector, so it must not be used for values with Go pointers
+type Allocator interface {
+	Allocate(size int, align int) (unsafe.Pointer, error)
+	Deallocate(pointer unsafe.Pointer) error
+	// Reset frees all allocations at once
+	Reset()
+	Stats() Stats
+}
+
+type Stats struct {
+	Capacity int
+	// Used is the number of bytes taken from the buffer, including alignment padding
+	Used          int
+	Live          int
+	Allocations   int
+	Deallocations int
+}
+
+type buffer struct {
+	memory []byte
+	stats  Stats
+}
+
+func newBuffer(capacity int) (buffer, error) {
+	if capacity <= 0 {
+		return buffer{}, ErrInvalidCapacity
+	}
+
+	return buffer{
+		memory: make([]byte, capacity),
+		stats:  Stats{Capacity: capacity},
+	}, nil
+}
+
+func validate(size int, align int) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+
+	if align <= 0 || align&(align-1) != 0 {
+		return ErrInvalidAlignment
+	}
+
+	return nil
+}
+
+// alignUp returns the first offset starting from which the address is aligned,
+// alignment is checked by the address because the buffer itself may be aligned less
+func (b *buffer) alignUp(offset int, align int) int {
+	address := b.address(offset)
+	aligned := (address + uintptr(align) - 1) &^ (uintptr(align) - 1)
+	return offset + int(aligned-address)
+}
+
+func (b *buffer) address(offset int) uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(b.memory))) + uintptr(offset)
+}
+
+func (b *buffer) pointer(offset int) unsafe.Pointer {
+	return unsafe.Pointer(&b.memory[offset])
+}
+
+// offsetOf returns the offset of pointer inside the buffer
+func (b *buffer) offsetOf(pointer unsafe.Pointer) (int, error) {
+	begin := b.address(0)
+	address := uintptr(pointer)
+	if pointer == nil || address < begin || address >= begin+uintptr(len(b.memory)) {
+		return 0, ErrInvalidPointer
+	}
+
+	return int(address - begin), nil
+}
+
+func (b *buffer) allocated(used int) {
+	b.stats.Used += used
+	b.stats.Live++
+	b.stats.Allocations++
+}
+
+func (b *buffer) deallocated(used int) {
+	b.stats.Used -= used
+	b.stats.Live--
+	b.stats.Deallocations++
+}
+
+func (b *buffer) reset() {
+	b.stats.Used = 0
+	b.stats.Live = 0
+}
+
+func (b *buffer) Stats() Stats {
+	return b.stats
+}
+
+func Store[T any](pointer unsafe.Pointer, value T) {
+	*(*T)(pointer) = value
+}
+
+func Load[T any](pointer unsafe.Pointer) T {
+	return *(*T)(pointer)
+}
@@ -0,0 +1,279 @@
+package allocator
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+const KB = 1 << 10
+
+var constructors = map[string]func() (Allocator, error){
+	"linear": func() (Allocator, error) {
+		return NewLinearAllocator(KB)
+	},
+	"stack": func() (Allocator, error) {
+		return NewStackAllocator(KB)
+	},
+	"pool": func() (Allocator, error) {
+		return NewPoolAllocator(KB, 16, 8)
+	},
+	"free list": func() (Allocator, error) {
+		return NewFreeListAllocator(KB)
+	},
+}
+
+func TestAllocators(t *testing.T) {
+	for name, constructor := range constructors {
+		t.Run(name, func(t *testing.T) {
+			allocator, err := constructor()
+			require.NoError(t, err)
+
+			pointer1, err := allocator.Allocate(2, 2)
+			require.NoError(t, err)
+			pointer2, err := allocator.Allocate(8, 8)
+			require.NoError(t, err)
+
+			assert.Zero(t, uintptr(pointer1)%2)
+			assert.Zero(t, uintptr(pointer2)%8)
+			assert.NotEqual(t, pointer1, pointer2)
+
+			Store[int16](pointer1, 100)
+			Store[int64](pointer2, 200)
+			assert.Equal(t, int16(100), Load[int16](pointer1))
+			assert.Equal(t, int64(200), Load[int64](pointer2))
+
+			stats := allocator.Stats()
+			assert.Equal(t, KB, stats.Capacity)
+			assert.Equal(t, 2, stats.Live)
+			assert.Equal(t, 2, stats.Allocations)
+			assert.Positive(t, stats.Used)
+
+			_, err = allocator.Allocate(0, 1)
+			assert.ErrorIs(t, err, ErrInvalidSize)
+			_, err = allocator.Allocate(4, 3)
+			assert.ErrorIs(t, err, ErrInvalidAlignment)
+
+			allocator.Reset()
+			stats = allocator.Stats()
+			assert.Zero(t, stats.Used)
+			assert.Zero(t, stats.Live)
+
+			pointer, err := allocator.Allocate(8, 8)
+			require.NoError(t, err)
+			assert.Zero(t, Load[int64](pointer))
+		})
+	}
+}
+
+func TestAllocatorsOutOfMemory(t *testing.T) {
+	for name, constructor := range constructors {
+		t.Run(name, func(t *testing.T) {
+			allocator, err := constructor()
+			require.NoError(t, err)
+
+			count := 0
+			for ; count <= KB; count++ {
+				if _, err = allocator.Allocate(16, 1); err != nil {
+					break
+				}
+			}
+
+			assert.ErrorIs(t, err, ErrOutOfMemory)
+			assert.GreaterOrEqual(t, count, KB/16-1)
+		})
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	_, err := NewLinearAllocator(0)
+	assert.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = NewStackAllocator(-1)
+	assert.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = NewFreeListAllocator(0)
+	assert.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = NewPoolAllocator(8, 16, 8)
+	assert.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = NewPoolAllocator(KB, 16, 6)
+	assert.ErrorIs(t, err, ErrInvalidAlignment)
+
+	outside := make([]byte, 8)
+	for name, constructor := range constructors {
+		t.Run(name, func(t *testing.T) {
+			allocator, err := constructor()
+			require.NoError(t, err)
+
+			err = allocator.Deallocate(unsafe.Pointer(&outside[0]))
+			assert.Error(t, err)
+			err = allocator.Deallocate(nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLinearAllocator(t *testing.T) {
+	allocator, err := NewLinearAllocator(KB)
+	require.NoError(t, err)
+
+	pointer, err := allocator.Allocate(1, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, allocator.Deallocate(pointer), ErrNotSupported)
+
+	_, err = allocator.Allocate(4, 4)
+	require.NoError(t, err)
+
+	// one byte, padding up to the alignment and four more bytes
+	used := allocator.Stats().Used
+	assert.GreaterOrEqual(t, used, 5)
+	assert.LessOrEqual(t, used, 8)
+}
+
+func TestStackAllocator(t *testing.T) {
+	allocator, err := NewStackAllocator(KB)
+	require.NoError(t, err)
+
+	pointer1, err := allocator.Allocate(1, 1)
+	require.NoError(t, err)
+	pointer2, err := allocator.Allocate(8, 8)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, allocator.Deallocate(pointer1), ErrInvalidPointer)
+	assert.NoError(t, allocator.Deallocate(pointer2))
+	assert.Equal(t, 1, allocator.Stats().Used)
+
+	// the freed space including padding is reused
+	pointer3, err := allocator.Allocate(8, 8)
+	require.NoError(t, err)
+	assert.Equal(t, pointer2, pointer3)
+
+	assert.NoError(t, allocator.Deallocate(pointer3))
+	assert.NoError(t, allocator.Deallocate(pointer1))
+	assert.ErrorIs(t, allocator.Deallocate(pointer1), ErrInvalidPointer)
+	assert.Zero(t, allocator.Stats().Used)
+}
+
+func TestPoolAllocator(t *testing.T) {
+	allocator, err := NewPoolAllocator(64, 12, 8)
+	require.NoError(t, err)
+
+	_, err = allocator.Allocate(17, 1)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+	_, err = allocator.Allocate(8, 32)
+	assert.ErrorIs(t, err, ErrInvalidAlignment)
+
+	// every allocation gets its own slot
+	seen := make(map[unsafe.Pointer]struct{})
+	for {
+		pointer, err := allocator.Allocate(16, 8)
+		if err != nil {
+			assert.ErrorIs(t, err, ErrOutOfMemory)
+			break
+		}
+
+		assert.NotContains(t, seen, pointer)
+		seen[pointer] = struct{}{}
+	}
+
+	assert.GreaterOrEqual(t, len(seen), 3)
+
+	for pointer := range seen {
+		assert.NoError(t, allocator.Deallocate(pointer))
+		assert.ErrorIs(t, allocator.Deallocate(pointer), ErrInvalidPointer)
+		assert.ErrorIs(t, allocator.Deallocate(unsafe.Add(pointer, 1)), ErrInvalidPointer)
+
+		reused, err := allocator.Allocate(8, 8)
+		require.NoError(t, err)
+		assert.Equal(t, pointer, reused)
+		break
+	}
+}
+
+func TestFreeListAllocatorCoalescing(t *testing.T) {
+	allocator, err := NewFreeListAllocator(KB)
+	require.NoError(t, err)
+
+	pointers := make([]unsafe.Pointer, 4)
+	for index := range pointers {
+		pointers[index], err = allocator.Allocate(KB/4, 1)
+		require.NoError(t, err)
+	}
+
+	_, err = allocator.Allocate(1, 1)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	assert.NoError(t, allocator.Deallocate(pointers[0]))
+	assert.NoError(t, allocator.Deallocate(pointers[2]))
+	assert.Equal(t, 2, allocator.FreeBlocks())
+
+	_, err = allocator.Allocate(KB/2, 1)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	// the middle block joins both free neighbours
+	assert.NoError(t, allocator.Deallocate(pointers[1]))
+	assert.Equal(t, 1, allocator.FreeBlocks())
+	assert.ErrorIs(t, allocator.Deallocate(pointers[1]), ErrInvalidPointer)
+
+	pointer, err := allocator.Allocate(3*KB/4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, pointers[0], pointer)
+}
+
+func TestFreeListAllocatorRandomWorkload(t *testing.T) {
+	allocator, err := NewFreeListAllocator(16 * KB)
+	require.NoError(t, err)
+
+	type allocation struct {
+		size  int
+		value byte
+	}
+
+	random := rand.New(rand.NewSource(1))
+	live := make(map[unsafe.Pointer]allocation)
+
+	for step := range 20_000 {
+		if len(live) > 0 && random.Intn(2) == 0 {
+			for pointer, allocation := range live {
+				data := unsafe.Slice((*byte)(pointer), allocation.size)
+				for _, value := range data {
+					require.Equal(t, allocation.value, value)
+				}
+
+				require.NoError(t, allocator.Deallocate(pointer))
+				delete(live, pointer)
+				break
+			}
+
+			continue
+		}
+
+		size := 1 + random.Intn(256)
+		align := 1 << random.Intn(5)
+		pointer, err := allocator.Allocate(size, align)
+		if err == ErrOutOfMemory {
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Zero(t, uintptr(pointer)%uintptr(align))
+
+		value := byte(step)
+		data := unsafe.Slice((*byte)(pointer), size)
+		for index := range data {
+			data[index] = value
+		}
+
+		live[pointer] = allocation{size: size, value: value}
+	}
+
+	for pointer := range live {
+		require.NoError(t, allocator.Deallocate(pointer))
+	}
+
+	assert.Equal(t, 1, allocator.FreeBlocks())
+	assert.Zero(t, allocator.Stats().Used)
+}
@@ -0,0 +1,78 @@
+package allocator
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// go test -bench=. -benchmem
+
+type object struct {
+	id      int64
+	balance int64
+	flags   [4]int32
+}
+
+const objectsPerRound = 64
+
+var Sink *object
+
+var objectSize = int(unsafe.Sizeof(object{}))
+var objectAlign = int(unsafe.Alignof(object{}))
+
+func BenchmarkHeap(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < objectsPerRound; j++ {
+			Sink = &object{id: int64(j)}
+		}
+	}
+}
+
+func benchmarkAllocator(b *testing.B, allocator Allocator, deallocate bool) {
+	pointers := make([]unsafe.Pointer, objectsPerRound)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range pointers {
+			pointer, err := allocator.Allocate(objectSize, objectAlign)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			(*object)(pointer).id = int64(j)
+			pointers[j] = pointer
+		}
+
+		if !deallocate {
+			allocator.Reset()
+			continue
+		}
+
+		// in the reverse order, so the stack allocator accepts it
+		for j := len(pointers) - 1; j >= 0; j-- {
+			if err := allocator.Deallocate(pointers[j]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkLinearAllocator(b *testing.B) {
+	allocator, _ := NewLinearAllocator(objectsPerRound * objectSize * 2)
+	benchmarkAllocator(b, allocator, false)
+}
+
+func BenchmarkStackAllocator(b *testing.B) {
+	allocator, _ := NewStackAllocator(objectsPerRound * objectSize * 2)
+	benchmarkAllocator(b, allocator, true)
+}
+
+func BenchmarkPoolAllocator(b *testing.B) {
+	allocator, _ := NewPoolAllocator(objectsPerRound*objectSize*2, objectSize, objectAlign)
+	benchmarkAllocator(b, allocator, true)
+}
+
+func BenchmarkFreeListAllocator(b *testing.B) {
+	allocator, _ := NewFreeListAllocator(objectsPerRound * objectSize * 2)
+	benchmarkAllocator(b, allocator, true)
+}
@@ -0,0 +1,119 @@
+package allocator
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// minBlockSize keeps split remainders from turning into unusable slivers
+const minBlockSize = 16
+
+type block struct {
+	offset int
+	size   int
+}
+
+// FreeListAllocator is a general-purpose first fit allocator,
+// freed blocks are merged with free neighbours to fight fragmentation
+type FreeListAllocator struct {
+	buffer
+	// free blocks sorted by offset, so neighbours are found by a binary search
+	free []block
+	// blocks maps the offset of a returned pointer to the block it was cut from
+	blocks map[int]block
+}
+
+func NewFreeListAllocator(capacity int) (*FreeListAllocator, error) {
+	buffer, err := newBuffer(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	allocator := &FreeListAllocator{
+		buffer: buffer,
+		blocks: make(map[int]block),
+	}
+
+	allocator.free = append(allocator.free, block{offset: 0, size: capacity})
+	return allocator, nil
+}
+
+func (a *FreeListAllocator) Allocate(size int, align int) (unsafe.Pointer, error) {
+	if err := validate(size, align); err != nil {
+		return nil, err
+	}
+
+	for index, free := range a.free {
+		offset := a.alignUp(free.offset, align)
+		needed := offset + size - free.offset
+		if needed > free.size {
+			continue
+		}
+
+		// the padding before the pointer stays in the block and is freed with it
+		used := block{offset: free.offset, size: free.size}
+		if remainder := free.size - needed; remainder >= minBlockSize {
+			used.size = needed
+			a.free[index] = block{offset: free.offset + needed, size: remainder}
+		} else {
+			a.free = append(a.free[:index], a.free[index+1:]...)
+		}
+
+		a.blocks[offset] = used
+		a.allocated(used.size)
+		return a.pointer(offset), nil
+	}
+
+	return nil, ErrOutOfMemory
+}
+
+func (a *FreeListAllocator) Deallocate(pointer unsafe.Pointer) error {
+	offset, err := a.offsetOf(pointer)
+	if err != nil {
+		return err
+	}
+
+	used, ok := a.blocks[offset]
+	if !ok {
+		return ErrInvalidPointer
+	}
+
+	delete(a.blocks, offset)
+	clear(a.memory[used.offset : used.offset+used.size])
+	a.deallocated(used.size)
+	a.insert(used)
+	return nil
+}
+
+// insert puts the block into the free list coalescing it with adjacent free blocks
+func (a *FreeListAllocator) insert(freed block) {
+	index := sort.Search(len(a.free), func(i int) bool {
+		return a.free[i].offset > freed.offset
+	})
+
+	if index < len(a.free) && freed.offset+freed.size == a.free[index].offset {
+		freed.size += a.free[index].size
+		a.free = append(a.free[:index], a.free[index+1:]...)
+	}
+
+	if index > 0 && a.free[index-1].offset+a.free[index-1].size == freed.offset {
+		a.free[index-1].size += freed.size
+		return
+	}
+
+	a.free = append(a.free, block{})
+	copy(a.free[index+1:], a.free[index:])
+	a.free[index] = freed
+}
+
+func (a *FreeListAllocator) Reset() {
+	clear(a.memory)
+	clear(a.blocks)
+	a.free = append(a.free[:0], block{offset: 0, size: len(a.memory)})
+	a.reset()
+}
+
+// FreeBlocks returns the number of free blocks, it's 1 when there's no fragmentation
+func (a *FreeListAllocator) FreeBlocks() int {
+	return len(a.free)
+}
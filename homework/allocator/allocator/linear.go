@@ -0,0 +1,43 @@
+package allocator
+
+import "unsafe"
+
+// LinearAllocator bumps an offset, memory is only reclaimed by Reset
+type LinearAllocator struct {
+	buffer
+	offset int
+}
+
+func NewLinearAllocator(capacity int) (*LinearAllocator, error) {
+	buffer, err := newBuffer(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinearAllocator{buffer: buffer}, nil
+}
+
+func (a *LinearAllocator) Allocate(size int, align int) (unsafe.Pointer, error) {
+	if err := validate(size, align); err != nil {
+		return nil, err
+	}
+
+	offset := a.alignUp(a.offset, align)
+	if offset+size > len(a.memory) {
+		return nil, ErrOutOfMemory
+	}
+
+	a.allocated(offset + size - a.offset)
+	a.offset = offset + size
+	return a.pointer(offset), nil
+}
+
+func (a *LinearAllocator) Deallocate(unsafe.Pointer) error {
+	return ErrNotSupported
+}
+
+func (a *LinearAllocator) Reset() {
+	clear(a.memory[:a.offset])
+	a.offset = 0
+	a.reset()
+}
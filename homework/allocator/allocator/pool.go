@@ -0,0 +1,103 @@
+package allocator
+
+import "unsafe"
+
+// PoolAllocator hands out fixed-size objects, every slot is aligned by objectAlign
+type PoolAllocator struct {
+	buffer
+	first      int
+	objectSize int
+	// freeObjects is a stack of free slot indices, so the last freed slot is reused first
+	freeObjects []int
+	used        []bool
+}
+
+func NewPoolAllocator(capacity int, objectSize int, objectAlign int) (*PoolAllocator, error) {
+	if err := validate(objectSize, objectAlign); err != nil {
+		return nil, err
+	}
+
+	buffer, err := newBuffer(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	// slots follow each other, so their size is rounded up to keep all of them aligned
+	objectSize = (objectSize + objectAlign - 1) &^ (objectAlign - 1)
+
+	allocator := &PoolAllocator{
+		buffer:     buffer,
+		objectSize: objectSize,
+	}
+
+	allocator.first = allocator.alignUp(0, objectAlign)
+	count := (capacity - allocator.first) / objectSize
+	if count <= 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	allocator.freeObjects = make([]int, 0, count)
+	allocator.used = make([]bool, count)
+	allocator.resetMemoryState()
+	return allocator, nil
+}
+
+func (a *PoolAllocator) Allocate(size int, align int) (unsafe.Pointer, error) {
+	if err := validate(size, align); err != nil {
+		return nil, err
+	}
+
+	if size > a.objectSize {
+		return nil, ErrInvalidSize
+	}
+
+	if a.alignUp(a.first, align) != a.first || a.objectSize%align != 0 {
+		return nil, ErrInvalidAlignment
+	}
+
+	if len(a.freeObjects) == 0 {
+		return nil, ErrOutOfMemory
+	}
+
+	// the slot is removed from the free list, so it can't be handed out twice
+	slot := a.freeObjects[len(a.freeObjects)-1]
+	a.freeObjects = a.freeObjects[:len(a.freeObjects)-1]
+	a.used[slot] = true
+
+	a.allocated(a.objectSize)
+	return a.pointer(a.first + slot*a.objectSize), nil
+}
+
+func (a *PoolAllocator) Deallocate(pointer unsafe.Pointer) error {
+	offset, err := a.offsetOf(pointer)
+	if err != nil {
+		return err
+	}
+
+	slot := (offset - a.first) / a.objectSize
+	if offset < a.first || (offset-a.first)%a.objectSize != 0 || slot >= len(a.used) || !a.used[slot] {
+		return ErrInvalidPointer
+	}
+
+	start := a.first + slot*a.objectSize
+	clear(a.memory[start : start+a.objectSize])
+
+	a.used[slot] = false
+	a.freeObjects = append(a.freeObjects, slot)
+	a.deallocated(a.objectSize)
+	return nil
+}
+
+func (a *PoolAllocator) Reset() {
+	clear(a.memory)
+	a.resetMemoryState()
+	a.reset()
+}
+
+func (a *PoolAllocator) resetMemoryState() {
+	a.freeObjects = a.freeObjects[:0]
+	for slot := len(a.used) - 1; slot >= 0; slot-- {
+		a.freeObjects = append(a.freeObjects, slot)
+		a.used[slot] = false
+	}
+}
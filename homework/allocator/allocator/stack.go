@@ -0,0 +1,68 @@
+package allocator
+
+import "unsafe"
+
+type frame struct {
+	offset int
+	// previous is the top of the stack before the allocation, so padding is given back too
+	previous int
+}
+
+// StackAllocator frees allocations in the reverse order only
+type StackAllocator struct {
+	buffer
+	top    int
+	frames []frame
+}
+
+func NewStackAllocator(capacity int) (*StackAllocator, error) {
+	buffer, err := newBuffer(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StackAllocator{buffer: buffer}, nil
+}
+
+func (a *StackAllocator) Allocate(size int, align int) (unsafe.Pointer, error) {
+	if err := validate(size, align); err != nil {
+		return nil, err
+	}
+
+	offset := a.alignUp(a.top, align)
+	if offset+size > len(a.memory) {
+		return nil, ErrOutOfMemory
+	}
+
+	a.frames = append(a.frames, frame{offset: offset, previous: a.top})
+	a.allocated(offset + size - a.top)
+	a.top = offset + size
+	return a.pointer(offset), nil
+}
+
+// Deallocate accepts only the most recent live allocation
+func (a *StackAllocator) Deallocate(pointer unsafe.Pointer) error {
+	offset, err := a.offsetOf(pointer)
+	if err != nil {
+		return err
+	}
+
+	if len(a.frames) == 0 || a.frames[len(a.frames)-1].offset != offset {
+		return ErrInvalidPointer
+	}
+
+	last := a.frames[len(a.frames)-1]
+	a.frames = a.frames[:len(a.frames)-1]
+
+	clear(a.memory[last.previous:a.top])
+	a.deallocated(a.top - last.previous)
+	a.top = last.previous
+	return nil
+}
+
+func (a *StackAllocator) Reset() {
+	clear(a.memory[:a.top])
+	a.top = 0
+	a.frames = a.frames[:0]
+	a.reset()
+}
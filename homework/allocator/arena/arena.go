@@ -0,0 +1,287 @@
+package arena
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+const defaultChunkSize = 64 << 10
+
+// poison fills memory of freed arenas in the debug mode, values read after
+// Free look like 0xDEDEDEDE... and writes after Free are found by Check
+const poison = 0xDE
+
+var (
+	ErrContainsPointers = errors.New("type contains pointers")
+	ErrInvalidSize      = errors.New("incorrect size")
+	ErrArenaFreed       = errors.New("arena is already freed")
+	ErrUseAfterFree     = errors.New("freed memory was modified")
+)
+
+var typeInfos sync.Map // map[reflect.Type]*typeInfo
+
+// typeInfo is built once per type, so allocations
+// don't walk the type with reflection every time
+type typeInfo struct {
+	size  uintptr
+	align int
+	err   error
+}
+
+// chunks of the default size are shared between arenas, so request
+// scoped arenas don't allocate after the first few requests
+var chunkPool = sync.Pool{
+	New: func() any {
+		chunk := make([]byte, defaultChunkSize)
+		return &chunk
+	},
+}
+
+type Option func(*Arena)
+
+func WithChunkSize(size int) Option {
+	return func(arena *Arena) {
+		if size > 0 {
+			arena.chunkSize = size
+		}
+	}
+}
+
+// WithDebug poisons memory on Free instead of reusing it
+func WithDebug() Option {
+	return func(arena *Arena) {
+		arena.debug = true
+	}
+}
+
+// Arena allocates values of pointer-free types from chunked []byte slabs
+// and frees all of them at once, it isn't safe for concurrent use
+type Arena struct {
+	chunks    [][]byte
+	current   []byte
+	offset    int
+	chunkSize int
+	allocated int
+	debug     bool
+	freed     bool
+}
+
+func NewArena(options ...Option) *Arena {
+	arena := &Arena{chunkSize: defaultChunkSize}
+	for _, option := range options {
+		option(arena)
+	}
+
+	return arena
+}
+
+func New[T any](a *Arena) (*T, error) {
+	info := typeInfoFor[T]()
+	if info.err != nil {
+		return nil, info.err
+	}
+
+	if info.size == 0 {
+		return new(T), nil
+	}
+
+	pointer, err := a.allocate(int(info.size), info.align)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*T)(pointer), nil
+}
+
+func MakeSlice[T any](a *Arena, length int, capacity int) ([]T, error) {
+	info := typeInfoFor[T]()
+	if info.err != nil {
+		return nil, info.err
+	}
+
+	if length < 0 || capacity < length {
+		return nil, ErrInvalidSize
+	}
+
+	if info.size == 0 || capacity == 0 {
+		return make([]T, length, capacity), nil
+	}
+
+	if uintptr(capacity) > (1<<62)/info.size {
+		return nil, ErrInvalidSize
+	}
+
+	pointer, err := a.allocate(capacity*int(info.size), info.align)
+	if err != nil {
+		return nil, err
+	}
+
+	return unsafe.Slice((*T)(pointer), capacity)[:length], nil
+}
+
+// Clone copies a value allocated in an arena to the heap, so it survives Free
+func Clone[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+
+	cloned := new(T)
+	*cloned = *value
+	return cloned
+}
+
+// CloneSlice copies a slice allocated in an arena to the heap, so it survives Free,
+// the capacity of the copy is its length
+func CloneSlice[T any](values []T) []T {
+	if values == nil {
+		return nil
+	}
+
+	cloned := make([]T, len(values))
+	copy(cloned, values)
+	return cloned
+}
+
+func (a *Arena) allocate(size int, align int) (unsafe.Pointer, error) {
+	if a.freed {
+		return nil, ErrArenaFreed
+	}
+
+	offset := a.alignUp(align)
+	if a.current == nil || offset+size > len(a.current) {
+		a.grow(size + align - 1)
+		offset = a.alignUp(align)
+	}
+
+	a.offset = offset + size
+	a.allocated += size
+	return unsafe.Pointer(&a.current[offset]), nil
+}
+
+func (a *Arena) alignUp(align int) int {
+	if a.current == nil {
+		return 0
+	}
+
+	address := uintptr(unsafe.Pointer(unsafe.SliceData(a.current))) + uintptr(a.offset)
+	aligned := (address + uintptr(align) - 1) &^ (uintptr(align) - 1)
+	return a.offset + int(aligned-address)
+}
+
+// grow starts a new chunk, values bigger than a chunk get a dedicated one
+func (a *Arena) grow(size int) {
+	var chunk []byte
+	switch {
+	case size > a.chunkSize:
+		chunk = make([]byte, size)
+	case a.chunkSize == defaultChunkSize && !a.debug:
+		chunk = *chunkPool.Get().(*[]byte)
+	default:
+		chunk = make([]byte, a.chunkSize)
+	}
+
+	a.chunks = append(a.chunks, chunk)
+	a.current = chunk
+	a.offset = 0
+}
+
+// Allocated returns the number of bytes handed out, alignment padding excluded
+func (a *Arena) Allocated() int {
+	return a.allocated
+}
+
+// Free releases all values at once, they must not be used afterwards,
+// in the debug mode their memory is poisoned and kept for Check
+func (a *Arena) Free() {
+	if a.freed {
+		return
+	}
+
+	for index, chunk := range a.chunks {
+		if a.debug {
+			for offset := range chunk {
+				chunk[offset] = poison
+			}
+
+			continue
+		}
+
+		if len(chunk) == defaultChunkSize {
+			// only the last chunk may be partially used
+			if index == len(a.chunks)-1 {
+				clear(chunk[:a.offset])
+			} else {
+				clear(chunk)
+			}
+
+			chunkPool.Put(&chunk)
+		}
+	}
+
+	if !a.debug {
+		a.chunks = nil
+	}
+
+	a.current = nil
+	a.offset = 0
+	a.freed = true
+}
+
+// Check reports writes to memory of a freed arena, it works in the debug mode only
+func (a *Arena) Check() error {
+	if !a.freed || !a.debug {
+		return nil
+	}
+
+	for index, chunk := range a.chunks {
+		for offset, value := range chunk {
+			if value != poison {
+				return fmt.Errorf("%w: chunk %d, offset %d", ErrUseAfterFree, index, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+func typeInfoFor[T any]() *typeInfo {
+	t := reflect.TypeFor[T]()
+	if cached, ok := typeInfos.Load(t); ok {
+		return cached.(*typeInfo)
+	}
+
+	info := &typeInfo{size: t.Size(), align: t.Align()}
+	if !pointerFree(t) {
+		info.err = fmt.Errorf("%w: %s", ErrContainsPointers, t)
+	}
+
+	cached, _ := typeInfos.LoadOrStore(t, info)
+	return cached.(*typeInfo)
+}
+
+// pointerFree reports whether values of the type can live in memory
+// that isn't scanned by the garbage collector
+func pointerFree(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return t.Len() == 0 || pointerFree(t.Elem())
+	case reflect.Struct:
+		for index := 0; index < t.NumField(); index++ {
+			if !pointerFree(t.Field(index).Type) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,189 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+type Data struct {
+	deposit int
+	credit  int
+	flags   [3]bool
+}
+
+type Operation struct {
+	amount int64
+	tags   []string
+}
+
+func TestNew(t *testing.T) {
+	a := NewArena()
+	defer a.Free()
+
+	value, err := New[int64](a)
+	require.NoError(t, err)
+	assert.Zero(t, *value)
+	*value = 100
+
+	data, err := New[Data](a)
+	require.NoError(t, err)
+	assert.Zero(t, uintptr(unsafe.Pointer(data))%unsafe.Alignof(Data{}))
+	data.deposit = 200
+
+	assert.Equal(t, int64(100), *value)
+	assert.Equal(t, 200, data.deposit)
+	assert.Equal(t, 8+int(unsafe.Sizeof(Data{})), a.Allocated())
+
+	empty, err := New[struct{}](a)
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+}
+
+func TestMakeSlice(t *testing.T) {
+	a := NewArena()
+	defer a.Free()
+
+	slice, err := MakeSlice[int32](a, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, slice, 0)
+	assert.Equal(t, 10, cap(slice))
+
+	for index := range 10 {
+		slice = append(slice, int32(index))
+	}
+
+	assert.Equal(t, []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, slice)
+
+	slice, err = MakeSlice[int32](a, 3, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{0, 0, 0}, slice)
+
+	_, err = MakeSlice[int32](a, 4, 3)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+	_, err = MakeSlice[int32](a, -1, 3)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestPointerTypes(t *testing.T) {
+	a := NewArena()
+	defer a.Free()
+
+	_, err := New[*int](a)
+	assert.ErrorIs(t, err, ErrContainsPointers)
+	_, err = New[Operation](a)
+	assert.ErrorIs(t, err, ErrContainsPointers)
+	_, err = New[[2]string](a)
+	assert.ErrorIs(t, err, ErrContainsPointers)
+	_, err = MakeSlice[any](a, 1, 1)
+	assert.ErrorIs(t, err, ErrContainsPointers)
+	_, err = MakeSlice[map[int]int](a, 1, 1)
+	assert.ErrorIs(t, err, ErrContainsPointers)
+
+	_, err = New[[0]*int](a)
+	assert.NoError(t, err)
+
+	// the check is done once per type
+	_, err = New[Operation](a)
+	assert.Equal(t, typeInfoFor[Operation]().err, err)
+}
+
+func TestChunks(t *testing.T) {
+	a := NewArena(WithChunkSize(64))
+	defer a.Free()
+
+	values := make([]*int64, 0, 32)
+	for index := range 32 {
+		value, err := New[int64](a)
+		require.NoError(t, err)
+		*value = int64(index)
+		values = append(values, value)
+	}
+
+	// values bigger than a chunk get their own one
+	big, err := MakeSlice[byte](a, 1000, 1000)
+	require.NoError(t, err)
+	big[999] = 1
+
+	for index, value := range values {
+		assert.Equal(t, int64(index), *value)
+	}
+
+	assert.Len(t, a.chunks, 5)
+}
+
+func TestFree(t *testing.T) {
+	a := NewArena()
+	data, err := New[Data](a)
+	require.NoError(t, err)
+
+	slice, err := MakeSlice[int64](a, 3, 8)
+	require.NoError(t, err)
+	copy(slice, []int64{1, 2, 3})
+	clonedSlice := CloneSlice(slice)
+	slice[0] = 100
+
+	cloned := Clone(data)
+	data.credit = 300
+	cloned.credit = 400
+
+	a.Free()
+	a.Free()
+
+	_, err = New[Data](a)
+	assert.ErrorIs(t, err, ErrArenaFreed)
+	assert.Nil(t, Clone[Data](nil))
+	assert.Equal(t, 400, cloned.credit)
+	assert.Equal(t, []int64{1, 2, 3}, clonedSlice)
+	assert.Nil(t, CloneSlice[int64](nil))
+
+	// chunks are reused zeroed by the following arenas
+	next := NewArena()
+	defer next.Free()
+
+	reused, err := New[Data](next)
+	require.NoError(t, err)
+	assert.Zero(t, *reused)
+}
+
+func TestDebugUseAfterFree(t *testing.T) {
+	a := NewArena(WithDebug())
+	data, err := New[Data](a)
+	require.NoError(t, err)
+	data.deposit = 100
+
+	a.Free()
+	assert.NoError(t, a.Check())
+
+	// reads after Free see poisoned memory
+	assert.Equal(t, uint64(0xDEDEDEDEDEDEDEDE), uint64(data.deposit))
+
+	data.credit = 1
+	assert.ErrorIs(t, a.Check(), ErrUseAfterFree)
+}
+
+var Sink *Data
+
+func BenchmarkHeap(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 64; j++ {
+			Sink = &Data{deposit: j}
+		}
+	}
+}
+
+func BenchmarkArena(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		a := NewArena()
+		for j := 0; j < 64; j++ {
+			Sink, _ = New[Data](a)
+			Sink.deposit = j
+		}
+
+		a.Free()
+	}
+}
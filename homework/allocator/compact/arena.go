@@ -0,0 +1,220 @@
+package compact
+
+import (
+	"errors"
+	"sort"
+	"unsafe"
+)
+
+var (
+	ErrInvalidCapacity  = errors.New("incorrect capacity")
+	ErrInvalidSize      = errors.New("incorrect size")
+	ErrInvalidAlignment = errors.New("alignment must be a power of two")
+	ErrOutOfMemory      = errors.New("not enough memory")
+	ErrInvalidHandle    = errors.New("invalid or freed handle")
+)
+
+// Handle stays valid while objects move, the low half indexes
+// the handle table and the high half is a generation of the slot,
+// so handles of freed objects aren't mixed up with reused slots
+type Handle uint64
+
+const InvalidHandle Handle = 0
+
+func newHandle(index int, generation uint32) Handle {
+	return Handle(uint64(generation)<<32 | uint64(index+1))
+}
+
+func (h Handle) index() int {
+	return int(uint32(h)) - 1
+}
+
+func (h Handle) generation() uint32 {
+	return uint32(h >> 32)
+}
+
+type entry struct {
+	offset     int
+	size       int
+	align      int
+	generation uint32
+	live       bool
+}
+
+type Stats struct {
+	Capacity int
+	// Used is the offset of the first never allocated byte
+	Used      int
+	LiveBytes int
+	Objects   int
+	// Fragmentation is the share of used memory taken by freed objects and alignment padding
+	Fragmentation float64
+	Compactions   int
+}
+
+// Arena is a bump allocator over a fixed buffer, freed space
+// is reclaimed by Compact which slides live objects down
+type Arena struct {
+	memory      []byte
+	used        int
+	liveBytes   int
+	entries     []entry
+	freeEntries []int
+	compactions int
+}
+
+func NewArena(capacity int) (*Arena, error) {
+	if capacity <= 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	return &Arena{memory: make([]byte, capacity)}, nil
+}
+
+// Allocate compacts the arena when the object doesn't fit at the end but
+// there's enough free space, so it invalidates slices returned by Bytes
+func (a *Arena) Allocate(size int, align int) (Handle, error) {
+	if size <= 0 {
+		return InvalidHandle, ErrInvalidSize
+	}
+
+	if align <= 0 || align&(align-1) != 0 {
+		return InvalidHandle, ErrInvalidAlignment
+	}
+
+	offset, ok := a.fit(a.used, size, align)
+	if !ok {
+		if a.liveBytes+size > len(a.memory) {
+			return InvalidHandle, ErrOutOfMemory
+		}
+
+		a.Compact()
+		if offset, ok = a.fit(a.used, size, align); !ok {
+			return InvalidHandle, ErrOutOfMemory
+		}
+	}
+
+	var index int
+	if length := len(a.freeEntries); length > 0 {
+		index = a.freeEntries[length-1]
+		a.freeEntries = a.freeEntries[:length-1]
+	} else {
+		a.entries = append(a.entries, entry{})
+		index = len(a.entries) - 1
+	}
+
+	e := &a.entries[index]
+	e.offset = offset
+	e.size = size
+	e.align = align
+	e.live = true
+
+	a.used = offset + size
+	a.liveBytes += size
+	return newHandle(index, e.generation), nil
+}
+
+// fit returns the first offset after from aligned by the address, not by the offset,
+// because the address of the buffer itself isn't aligned to big alignments
+func (a *Arena) fit(from int, size int, align int) (int, bool) {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(a.memory)))
+	address := base + uintptr(from)
+	aligned := (address + uintptr(align) - 1) &^ (uintptr(align) - 1)
+
+	offset := from + int(aligned-address)
+	return offset, offset+size <= len(a.memory)
+}
+
+func (a *Arena) Free(handle Handle) error {
+	e, err := a.entry(handle)
+	if err != nil {
+		return err
+	}
+
+	clear(a.memory[e.offset : e.offset+e.size])
+	a.liveBytes -= e.size
+
+	e.live = false
+	e.generation++
+	a.freeEntries = append(a.freeEntries, handle.index())
+
+	// freeing the last live object gives the whole buffer back without compaction
+	if a.liveBytes == 0 {
+		a.used = 0
+	}
+
+	return nil
+}
+
+// Bytes returns memory of the object, the slice is valid until the next Allocate or Compact
+func (a *Arena) Bytes(handle Handle) ([]byte, error) {
+	e, err := a.entry(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.memory[e.offset : e.offset+e.size : e.offset+e.size], nil
+}
+
+// Compact slides live objects down in address order, so their relative
+// order is kept and only the handle table has to be updated
+func (a *Arena) Compact() {
+	live := make([]int, 0, len(a.entries)-len(a.freeEntries))
+	for index := range a.entries {
+		if a.entries[index].live {
+			live = append(live, index)
+		}
+	}
+
+	sort.Slice(live, func(i, j int) bool {
+		return a.entries[live[i]].offset < a.entries[live[j]].offset
+	})
+
+	current := 0
+	for _, index := range live {
+		e := &a.entries[index]
+
+		// objects only move down, so nothing can be overwritten before it's moved
+		offset, _ := a.fit(current, e.size, e.align)
+		if offset != e.offset {
+			copy(a.memory[offset:offset+e.size], a.memory[e.offset:e.offset+e.size])
+			e.offset = offset
+		}
+
+		current = offset + e.size
+	}
+
+	clear(a.memory[current:a.used])
+	a.used = current
+	a.compactions++
+}
+
+func (a *Arena) Stats() Stats {
+	stats := Stats{
+		Capacity:    len(a.memory),
+		Used:        a.used,
+		LiveBytes:   a.liveBytes,
+		Objects:     len(a.entries) - len(a.freeEntries),
+		Compactions: a.compactions,
+	}
+
+	if a.used > 0 {
+		stats.Fragmentation = float64(a.used-a.liveBytes) / float64(a.used)
+	}
+
+	return stats
+}
+
+func (a *Arena) entry(handle Handle) (*entry, error) {
+	index := handle.index()
+	if index < 0 || index >= len(a.entries) {
+		return nil, ErrInvalidHandle
+	}
+
+	e := &a.entries[index]
+	if !e.live || e.generation != handle.generation() {
+		return nil, ErrInvalidHandle
+	}
+
+	return e, nil
+}
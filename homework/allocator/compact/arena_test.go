@@ -0,0 +1,192 @@
+package compact
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func fill(t *testing.T, arena *Arena, handle Handle, value byte) {
+	data, err := arena.Bytes(handle)
+	require.NoError(t, err)
+
+	for index := range data {
+		data[index] = value
+	}
+}
+
+func address(t *testing.T, arena *Arena, handle Handle) uintptr {
+	data, err := arena.Bytes(handle)
+	require.NoError(t, err)
+	return uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+}
+
+func TestCompact(t *testing.T) {
+	arena, err := NewArena(64)
+	require.NoError(t, err)
+
+	first, err := arena.Allocate(8, 1)
+	require.NoError(t, err)
+	second, err := arena.Allocate(8, 1)
+	require.NoError(t, err)
+	third, err := arena.Allocate(8, 1)
+	require.NoError(t, err)
+
+	fill(t, arena, first, 0xF0)
+	fill(t, arena, second, 0xF1)
+	fill(t, arena, third, 0xF2)
+	firstAddress := address(t, arena, first)
+
+	require.NoError(t, arena.Free(second))
+	assert.InDelta(t, 1.0/3, arena.Stats().Fragmentation, 1e-9)
+
+	arena.Compact()
+
+	data, err := arena.Bytes(third)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xF2}, 8), data)
+	assert.Equal(t, firstAddress+8, address(t, arena, third))
+	assert.Equal(t, firstAddress, address(t, arena, first))
+
+	stats := arena.Stats()
+	assert.Equal(t, 16, stats.Used)
+	assert.Equal(t, 16, stats.LiveBytes)
+	assert.Equal(t, 2, stats.Objects)
+	assert.Equal(t, 1, stats.Compactions)
+	assert.Zero(t, stats.Fragmentation)
+}
+
+func TestAlignment(t *testing.T) {
+	arena, err := NewArena(1024)
+	require.NoError(t, err)
+
+	small, err := arena.Allocate(3, 1)
+	require.NoError(t, err)
+
+	aligned := make([]Handle, 0, 4)
+	for _, align := range []int{2, 8, 16, 64} {
+		handle, err := arena.Allocate(5, align)
+		require.NoError(t, err)
+		assert.Zero(t, address(t, arena, handle)%uintptr(align))
+		aligned = append(aligned, handle)
+	}
+
+	require.NoError(t, arena.Free(small))
+	arena.Compact()
+
+	for index, align := range []int{2, 8, 16, 64} {
+		assert.Zero(t, address(t, arena, aligned[index])%uintptr(align))
+	}
+
+	_, err = arena.Allocate(8, 3)
+	assert.ErrorIs(t, err, ErrInvalidAlignment)
+	_, err = arena.Allocate(0, 1)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestAllocateCompactsWhenFragmented(t *testing.T) {
+	arena, err := NewArena(32)
+	require.NoError(t, err)
+
+	handles := make([]Handle, 4)
+	for index := range handles {
+		handles[index], err = arena.Allocate(8, 1)
+		require.NoError(t, err)
+		fill(t, arena, handles[index], byte(index))
+	}
+
+	_, err = arena.Allocate(1, 1)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	require.NoError(t, arena.Free(handles[0]))
+	require.NoError(t, arena.Free(handles[2]))
+
+	_, err = arena.Allocate(17, 1)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	large, err := arena.Allocate(16, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, arena.Stats().Compactions)
+
+	for _, index := range []int{1, 3} {
+		data, err := arena.Bytes(handles[index])
+		require.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{byte(index)}, 8), data)
+	}
+
+	data, err := arena.Bytes(large)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 16), data)
+}
+
+func TestStaleHandles(t *testing.T) {
+	arena, err := NewArena(32)
+	require.NoError(t, err)
+
+	handle, err := arena.Allocate(8, 1)
+	require.NoError(t, err)
+	require.NoError(t, arena.Free(handle))
+
+	assert.ErrorIs(t, arena.Free(handle), ErrInvalidHandle)
+
+	// the slot of the table is reused, but the old handle stays invalid
+	reused, err := arena.Allocate(8, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, handle, reused)
+
+	_, err = arena.Bytes(handle)
+	assert.ErrorIs(t, err, ErrInvalidHandle)
+	_, err = arena.Bytes(InvalidHandle)
+	assert.ErrorIs(t, err, ErrInvalidHandle)
+	_, err = arena.Bytes(Handle(100))
+	assert.ErrorIs(t, err, ErrInvalidHandle)
+
+	_, err = NewArena(0)
+	assert.ErrorIs(t, err, ErrInvalidCapacity)
+}
+
+func TestRandomWorkload(t *testing.T) {
+	arena, err := NewArena(4096)
+	require.NoError(t, err)
+
+	random := rand.New(rand.NewSource(1))
+	contents := make(map[Handle][]byte)
+
+	for range 10_000 {
+		if len(contents) > 0 && random.Intn(3) == 0 {
+			for handle := range contents {
+				require.NoError(t, arena.Free(handle))
+				delete(contents, handle)
+				break
+			}
+
+			continue
+		}
+
+		size := 1 + random.Intn(64)
+		handle, err := arena.Allocate(size, 1<<random.Intn(4))
+		if err == ErrOutOfMemory {
+			continue
+		}
+
+		require.NoError(t, err)
+		data, err := arena.Bytes(handle)
+		require.NoError(t, err)
+		random.Read(data)
+		contents[handle] = bytes.Clone(data)
+	}
+
+	for handle, expected := range contents {
+		data, err := arena.Bytes(handle)
+		require.NoError(t, err)
+		assert.Equal(t, expected, data)
+	}
+
+	assert.Positive(t, arena.Stats().Compactions)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"testing"
 	"unsafe"
 
@@ -14,21 +18,125 @@ type Number interface {
 	byte | int | int8 | int16 | int32 | int64
 }
 
-func Defragment[T Number](memory []T, pointers []unsafe.Pointer) {
-	currentPointer := 0
-	changes := make(map[unsafe.Pointer]unsafe.Pointer)
+var (
+	ErrPointerOutOfMemory = errors.New("pointer doesn't point to an element of memory")
+	ErrPointerOutOfBlock  = errors.New("pointer doesn't point into any block")
+	ErrInvalidBlock       = errors.New("block is out of memory or overlaps another block")
+)
 
-	for index, pointer := range pointers {
-		if changes[pointer] == nil {
-			changes[pointer] = unsafe.Pointer(&memory[currentPointer])
+// Block is a live region of memory, offset and length are counted in elements
+type Block struct {
+	Offset int
+	Length int
+}
+
+// Defragment moves elements referenced by pointers to the beginning of memory
+// in the order of the first reference and rewrites pointers, the rest of memory is cleared
+func Defragment[T Number](memory []T, pointers []unsafe.Pointer) error {
+	indices, err := elementIndices(memory, pointers)
+	if err != nil {
+		return err
+	}
+
+	// values are collected before moving, so a move can't clobber an element that isn't moved yet
+	moved := make(map[int]int, len(indices))
+	var values []T
+	for _, index := range indices {
+		if _, ok := moved[index]; !ok {
+			moved[index] = len(values)
+			values = append(values, memory[index])
+		}
+	}
+
+	copy(memory, values)
+	clear(memory[len(values):])
+
+	for index := range pointers {
+		pointers[index] = unsafe.Pointer(&memory[moved[indices[index]]])
+	}
+
+	return nil
+}
+
+// DefragmentBlocks slides blocks down keeping their relative order, updates their offsets
+// and rewrites pointers, that may point anywhere inside blocks, the rest of memory is cleared
+func DefragmentBlocks[T Number](memory []T, blocks []Block, pointers []unsafe.Pointer) error {
+	order := make([]int, len(blocks))
+	for index, block := range blocks {
+		// the sum of offset and length may overflow, so the length is subtracted instead
+		if block.Offset < 0 || block.Length <= 0 || block.Offset > len(memory)-block.Length {
+			return fmt.Errorf("block %d: %w", index, ErrInvalidBlock)
+		}
+
+		order[index] = index
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		return blocks[order[i]].Offset < blocks[order[j]].Offset
+	})
+
+	for index := 1; index < len(order); index++ {
+		previous := blocks[order[index-1]]
+		if previous.Offset+previous.Length > blocks[order[index]].Offset {
+			return fmt.Errorf("block %d: %w", order[index], ErrInvalidBlock)
+		}
+	}
+
+	indices, err := elementIndices(memory, pointers)
+	if err != nil {
+		return err
+	}
+
+	// every pointer is resolved to its block before anything moves
+	owners := make([]int, len(pointers))
+	for index, element := range indices {
+		position := sort.Search(len(order), func(i int) bool {
+			block := blocks[order[i]]
+			return block.Offset+block.Length > element
+		})
+
+		if position == len(order) || blocks[order[position]].Offset > element {
+			return fmt.Errorf("pointer %d: %w", index, ErrPointerOutOfBlock)
+		}
+
+		owners[index] = order[position]
+		indices[index] = element - blocks[order[position]].Offset
+	}
+
+	current := 0
+	for _, index := range order {
+		block := &blocks[index]
+		copy(memory[current:current+block.Length], memory[block.Offset:block.Offset+block.Length])
+		block.Offset = current
+		current += block.Length
+	}
+
+	clear(memory[current:])
+
+	for index := range pointers {
+		pointers[index] = unsafe.Pointer(&memory[blocks[owners[index]].Offset+indices[index]])
+	}
 
-			memory[currentPointer], *(*T)(pointers[index]) = *(*T)(pointers[index]), memory[currentPointer]
+	return nil
+}
+
+func elementIndices[T Number](memory []T, pointers []unsafe.Pointer) ([]int, error) {
+	var zero T
+	size := unsafe.Sizeof(zero)
+	begin := uintptr(unsafe.Pointer(unsafe.SliceData(memory)))
+	end := begin + uintptr(len(memory))*size
 
-			currentPointer++
+	indices := make([]int, len(pointers))
+	for index, pointer := range pointers {
+		address := uintptr(pointer)
+		if address < begin || address >= end || (address-begin)%size != 0 {
+			return nil, fmt.Errorf("pointer %d: %w", index, ErrPointerOutOfMemory)
 		}
 
-		pointers[index] = changes[pointer]
+		indices[index] = int((address - begin) / size)
 	}
+
+	return indices, nil
 }
 
 func TestDefragmentation(t *testing.T) {
@@ -64,7 +172,7 @@ func TestDefragmentation(t *testing.T) {
 		0x00, 0x00, 0x00, 0x00,
 	}
 
-	Defragment(fragmentedMemory, fragmentedPointers)
+	assert.NoError(t, Defragment(fragmentedMemory, fragmentedPointers))
 	assert.True(t, reflect.DeepEqual(defragmentedMemory, fragmentedMemory))
 	assert.True(t, reflect.DeepEqual(defragmentedPointers, fragmentedPointers))
 }
@@ -102,7 +210,155 @@ func TestDefragmentationInt64(t *testing.T) {
 		0x00, 0x00, 0x00, 0x00,
 	}
 
-	Defragment(fragmentedMemory, fragmentedPointers)
+	assert.NoError(t, Defragment(fragmentedMemory, fragmentedPointers))
 	assert.True(t, reflect.DeepEqual(defragmentedMemory, fragmentedMemory))
 	assert.True(t, reflect.DeepEqual(defragmentedPointers, fragmentedPointers))
 }
+
+func TestDefragmentationDoesNotClobber(t *testing.T) {
+	memory := []int16{0xF0, 0x00, 0xF1, 0x00, 0xF2}
+	pointers := []unsafe.Pointer{
+		unsafe.Pointer(&memory[4]),
+		unsafe.Pointer(&memory[0]),
+		unsafe.Pointer(&memory[2]),
+	}
+
+	assert.NoError(t, Defragment(memory, pointers))
+	assert.Equal(t, []int16{0xF2, 0xF0, 0xF1, 0x00, 0x00}, memory)
+	assert.Equal(t, []unsafe.Pointer{
+		unsafe.Pointer(&memory[0]),
+		unsafe.Pointer(&memory[1]),
+		unsafe.Pointer(&memory[2]),
+	}, pointers)
+}
+
+func TestDefragmentationInvalidPointers(t *testing.T) {
+	backing := []int32{0xF0, 0x00, 0xF1, 0x00, 0xF3}
+	memory := backing[:4]
+	outside := []int32{0xF2}
+
+	tests := map[string]struct {
+		pointer unsafe.Pointer
+	}{
+		"pointer to another slice": {
+			pointer: unsafe.Pointer(&outside[0]),
+		},
+		"pointer past the end": {
+			pointer: unsafe.Pointer(&backing[4]),
+		},
+		"pointer to the middle of an element": {
+			pointer: unsafe.Add(unsafe.Pointer(&memory[2]), 1),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pointers := []unsafe.Pointer{unsafe.Pointer(&memory[2]), test.pointer}
+			err := Defragment(memory, pointers)
+			assert.ErrorIs(t, err, ErrPointerOutOfMemory)
+
+			// nothing is moved when validation fails
+			assert.Equal(t, []int32{0xF0, 0x00, 0xF1, 0x00}, memory)
+			assert.Equal(t, unsafe.Pointer(&memory[2]), pointers[0])
+		})
+	}
+}
+
+func TestDefragmentBlocks(t *testing.T) {
+	memory := []byte{
+		0x00, 0xA0, 0xA1, 0xA2,
+		0x00, 0x00, 0xB0, 0x00,
+		0xC0, 0xC1, 0x00, 0x00,
+	}
+
+	blocks := []Block{
+		{Offset: 8, Length: 2},
+		{Offset: 1, Length: 3},
+		{Offset: 6, Length: 1},
+	}
+
+	pointers := []unsafe.Pointer{
+		unsafe.Pointer(&memory[9]),
+		unsafe.Pointer(&memory[1]),
+		unsafe.Pointer(&memory[3]),
+		unsafe.Pointer(&memory[2]),
+		unsafe.Pointer(&memory[6]),
+		unsafe.Pointer(&memory[3]),
+	}
+
+	assert.NoError(t, DefragmentBlocks(memory, blocks, pointers))
+	assert.Equal(t, []byte{
+		0xA0, 0xA1, 0xA2, 0xB0,
+		0xC0, 0xC1, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}, memory)
+	assert.Equal(t, []Block{
+		{Offset: 4, Length: 2},
+		{Offset: 0, Length: 3},
+		{Offset: 3, Length: 1},
+	}, blocks)
+	assert.Equal(t, []unsafe.Pointer{
+		unsafe.Pointer(&memory[5]),
+		unsafe.Pointer(&memory[0]),
+		unsafe.Pointer(&memory[2]),
+		unsafe.Pointer(&memory[1]),
+		unsafe.Pointer(&memory[3]),
+		unsafe.Pointer(&memory[2]),
+	}, pointers)
+}
+
+func TestDefragmentBlocksInt64(t *testing.T) {
+	memory := []int64{0x00, 0x00, 0xA0, 0xA1, 0x00, 0xB0, 0xB1, 0xB2}
+	blocks := []Block{{Offset: 2, Length: 2}, {Offset: 5, Length: 3}}
+	pointers := []unsafe.Pointer{unsafe.Pointer(&memory[7]), unsafe.Pointer(&memory[3])}
+
+	assert.NoError(t, DefragmentBlocks(memory, blocks, pointers))
+	assert.Equal(t, []int64{0xA0, 0xA1, 0xB0, 0xB1, 0xB2, 0x00, 0x00, 0x00}, memory)
+	assert.Equal(t, []Block{{Offset: 0, Length: 2}, {Offset: 2, Length: 3}}, blocks)
+	assert.Equal(t, []unsafe.Pointer{unsafe.Pointer(&memory[4]), unsafe.Pointer(&memory[1])}, pointers)
+}
+
+func TestDefragmentBlocksErrors(t *testing.T) {
+	memory := make([]byte, 8)
+	outside := make([]byte, 1)
+
+	tests := map[string]struct {
+		blocks   []Block
+		pointers []unsafe.Pointer
+		err      error
+	}{
+		"block out of memory": {
+			blocks: []Block{{Offset: 6, Length: 3}},
+			err:    ErrInvalidBlock,
+		},
+		"empty block": {
+			blocks: []Block{{Offset: 2, Length: 0}},
+			err:    ErrInvalidBlock,
+		},
+		"overflowing block": {
+			blocks: []Block{{Offset: 2, Length: math.MaxInt}},
+			err:    ErrInvalidBlock,
+		},
+		"overlapping blocks": {
+			blocks: []Block{{Offset: 3, Length: 2}, {Offset: 1, Length: 3}},
+			err:    ErrInvalidBlock,
+		},
+		"pointer out of memory": {
+			blocks:   []Block{{Offset: 1, Length: 3}},
+			pointers: []unsafe.Pointer{unsafe.Pointer(&outside[0])},
+			err:      ErrPointerOutOfMemory,
+		},
+		"pointer between blocks": {
+			blocks:   []Block{{Offset: 1, Length: 2}, {Offset: 5, Length: 2}},
+			pointers: []unsafe.Pointer{unsafe.Pointer(&memory[4])},
+			err:      ErrPointerOutOfBlock,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := DefragmentBlocks(memory, test.blocks, test.pointers)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package slab
+
+import "unsafe"
+
+// Cache is a per-worker front of the heap like mcache is per P in the runtime,
+// it isn't safe for concurrent use, so every worker has to own one
+type Cache struct {
+	heap    *Heap
+	objects [numClasses][]unsafe.Pointer
+}
+
+func (h *Heap) NewCache() *Cache {
+	return &Cache{heap: h}
+}
+
+// Allocate returns zeroed memory, it must not be used for values with Go pointers
+// because the garbage collector doesn't scan it
+func (c *Cache) Allocate(size int) (unsafe.Pointer, error) {
+	if size <= 0 {
+		return nil, ErrInvalidSize
+	}
+
+	if size > maxSmallSize {
+		return c.heap.allocateLarge(size), nil
+	}
+
+	class := classOf(size)
+	objects := c.objects[class]
+	if len(objects) == 0 {
+		objects = c.heap.refill(class, objects)
+	}
+
+	pointer := objects[len(objects)-1]
+	c.objects[class] = objects[:len(objects)-1]
+	c.heap.centrals[class].inUse.Add(1)
+	return pointer, nil
+}
+
+// Free accepts objects allocated by any cache of the same heap,
+// freeing an object twice corrupts the heap like it does in C
+func (c *Cache) Free(pointer unsafe.Pointer) error {
+	s, err := c.heap.spanOf(pointer)
+	if err != nil {
+		return err
+	}
+
+	if s.class == 0 {
+		c.heap.freeLarge(s)
+		return nil
+	}
+
+	clear(unsafe.Slice((*byte)(pointer), s.size))
+	c.heap.centrals[s.class].inUse.Add(-1)
+
+	objects := append(c.objects[s.class], pointer)
+
+	// half of the cached objects go back, so alternating calls don't flush every time
+	if limit := 2 * c.heap.batchSize; len(objects) > limit {
+		keep := len(objects) - c.heap.batchSize
+		c.heap.flush(s.class, objects[keep:])
+		objects = objects[:keep]
+	}
+
+	c.objects[s.class] = objects
+	return nil
+}
+
+// Flush returns all cached objects to the heap, a cache has to be flushed before it's dropped
+func (c *Cache) Flush() {
+	for class := range c.objects {
+		if len(c.objects[class]) > 0 {
+			c.heap.flush(class, c.objects[class])
+			c.objects[class] = c.objects[class][:0]
+		}
+	}
+}
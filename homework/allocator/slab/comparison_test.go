@@ -0,0 +1,79 @@
+package slab
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// go test -bench=. -benchmem
+
+type Person struct {
+	id      int64
+	balance int64
+	age     int32
+}
+
+var Sink *Person
+
+func BenchmarkWithoutPool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Sink = &Person{id: int64(i)}
+	}
+}
+
+func BenchmarkSyncPool(b *testing.B) {
+	pool := sync.Pool{
+		New: func() any { return new(Person) },
+	}
+
+	for i := 0; i < b.N; i++ {
+		person := pool.Get().(*Person)
+		*person = Person{id: int64(i)} // need to reset values
+		Sink = person
+		pool.Put(person)
+	}
+}
+
+func BenchmarkSlab(b *testing.B) {
+	cache := NewHeap().NewCache()
+	defer cache.Flush()
+
+	for i := 0; i < b.N; i++ {
+		pointer, _ := cache.Allocate(int(unsafe.Sizeof(Person{})))
+		person := (*Person)(pointer)
+		person.id = int64(i)
+		Sink = person
+		_ = cache.Free(pointer)
+	}
+}
+
+func BenchmarkSyncPoolParallel(b *testing.B) {
+	pool := sync.Pool{
+		New: func() any { return new(Person) },
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			person := pool.Get().(*Person)
+			*person = Person{}
+			pool.Put(person)
+		}
+	})
+}
+
+func BenchmarkSlabParallel(b *testing.B) {
+	heap := NewHeap()
+
+	b.RunParallel(func(pb *testing.PB) {
+		// every goroutine owns its cache like every P owns its mcache
+		cache := heap.NewCache()
+		defer cache.Flush()
+
+		for pb.Next() {
+			pointer, _ := cache.Allocate(int(unsafe.Sizeof(Person{})))
+			(*Person)(pointer).id = 1
+			_ = cache.Free(pointer)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package slab
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const defaultBatchSize = 32
+
+// maxFreeSpans limits span memory kept for reuse per number of pages
+const maxFreeSpans = 64
+
+var (
+	ErrInvalidSize    = errors.New("incorrect size")
+	ErrInvalidPointer = errors.New("incorrect pointer")
+)
+
+// span is a run of pages cut into objects of a single size class
+type span struct {
+	memory []byte
+	class  int
+	size   int
+	// free holds indices of objects that aren't handed out to caches
+	free []int32
+	// allocated counts objects in caches and in use, the span is released when it drops to zero
+	allocated int
+	// partial tells whether the span is in the partial list of its central
+	partial bool
+}
+
+func (s *span) base() uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(s.memory)))
+}
+
+// central owns spans of a single size class and hands out objects in batches
+type central struct {
+	mutex   sync.Mutex
+	partial []*span
+	spans   atomic.Int64
+	inUse   atomic.Int64
+}
+
+type Option func(*Heap)
+
+// WithBatchSize sets how many objects a cache takes from a central list at once
+func WithBatchSize(size int) Option {
+	return func(heap *Heap) {
+		if size > 0 {
+			heap.batchSize = size
+		}
+	}
+}
+
+// Heap is the shared part of the allocator: per size class central lists
+// and span memory, workers allocate through their own Cache
+type Heap struct {
+	mutex     sync.Mutex
+	freeSpans map[int][][]byte
+	pages     sync.Map // map[uintptr]*span, keyed by page number
+	centrals  [numClasses]central
+	batchSize int
+
+	largeObjects atomic.Int64
+	largeBytes   atomic.Int64
+}
+
+func NewHeap(options ...Option) *Heap {
+	heap := &Heap{
+		freeSpans: make(map[int][][]byte),
+		batchSize: defaultBatchSize,
+	}
+
+	for _, option := range options {
+		option(heap)
+	}
+
+	return heap
+}
+
+// allocateSpan returns page aligned memory, so the span of any pointer
+// is found by its page number like the runtime does with its page map
+func (h *Heap) allocateSpan(pages int, class int) *span {
+	h.mutex.Lock()
+	var memory []byte
+	if free := h.freeSpans[pages]; len(free) > 0 {
+		memory = free[len(free)-1]
+		h.freeSpans[pages] = free[:len(free)-1]
+	}
+	h.mutex.Unlock()
+
+	if memory == nil {
+		raw := make([]byte, (pages+1)*pageSize)
+		address := uintptr(unsafe.Pointer(unsafe.SliceData(raw)))
+		offset := int((pageSize - address%pageSize) % pageSize)
+		memory = raw[offset : offset+pages*pageSize : offset+pages*pageSize]
+	}
+
+	s := &span{memory: memory, class: class, size: classSizes[class]}
+	first := s.base() >> pageShift
+	for page := range uintptr(pages) {
+		h.pages.Store(first+page, s)
+	}
+
+	return s
+}
+
+func (h *Heap) releaseSpan(s *span) {
+	pages := len(s.memory) / pageSize
+	first := s.base() >> pageShift
+	for page := range uintptr(pages) {
+		h.pages.Delete(first + page)
+	}
+
+	clear(s.memory)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.freeSpans[pages]) < maxFreeSpans {
+		h.freeSpans[pages] = append(h.freeSpans[pages], s.memory)
+	}
+}
+
+func (h *Heap) spanOf(pointer unsafe.Pointer) (*span, error) {
+	if pointer == nil {
+		return nil, ErrInvalidPointer
+	}
+
+	value, ok := h.pages.Load(uintptr(pointer) >> pageShift)
+	if !ok {
+		return nil, ErrInvalidPointer
+	}
+
+	s := value.(*span)
+	offset := uintptr(pointer) - s.base()
+	if s.class == 0 && offset != 0 {
+		return nil, ErrInvalidPointer
+	}
+
+	// the tail of a span that doesn't fit a whole object isn't an object
+	if s.class != 0 && (offset%uintptr(s.size) != 0 || offset/uintptr(s.size) >= uintptr(len(s.memory)/s.size)) {
+		return nil, ErrInvalidPointer
+	}
+
+	return s, nil
+}
+
+// refill appends up to a batch of objects of the class to objects
+func (h *Heap) refill(class int, objects []unsafe.Pointer) []unsafe.Pointer {
+	c := &h.centrals[class]
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for count := 0; count < h.batchSize; {
+		if len(c.partial) == 0 {
+			// a new span is cut only when nothing is taken yet, so big classes don't grab many spans
+			if count > 0 {
+				break
+			}
+
+			c.partial = append(c.partial, h.newSmallSpan(class))
+			c.spans.Add(1)
+		}
+
+		s := c.partial[len(c.partial)-1]
+		take := min(h.batchSize-count, len(s.free))
+		for _, index := range s.free[len(s.free)-take:] {
+			objects = append(objects, unsafe.Pointer(&s.memory[int(index)*s.size]))
+		}
+
+		s.free = s.free[:len(s.free)-take]
+		s.allocated += take
+		count += take
+
+		if len(s.free) == 0 {
+			s.partial = false
+			c.partial = c.partial[:len(c.partial)-1]
+		}
+	}
+
+	return objects
+}
+
+func (h *Heap) newSmallSpan(class int) *span {
+	s := h.allocateSpan(classPages[class], class)
+
+	count := len(s.memory) / s.size
+	s.free = make([]int32, count)
+	// objects are popped from the end, so the lowest addresses go first
+	for index := range s.free {
+		s.free[index] = int32(count - 1 - index)
+	}
+
+	s.partial = true
+	return s
+}
+
+// flush returns objects from a cache to their spans, empty spans go back to the heap
+func (h *Heap) flush(class int, objects []unsafe.Pointer) {
+	c := &h.centrals[class]
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, pointer := range objects {
+		value, _ := h.pages.Load(uintptr(pointer) >> pageShift)
+		s := value.(*span)
+
+		s.free = append(s.free, int32((uintptr(pointer)-s.base())/uintptr(s.size)))
+		s.allocated--
+
+		if !s.partial {
+			s.partial = true
+			c.partial = append(c.partial, s)
+		}
+
+		// one empty span is kept, so alternating refills and flushes don't cut spans every time
+		if s.allocated == 0 && len(c.partial) > 1 {
+			h.removePartial(c, s)
+			c.spans.Add(-1)
+			h.releaseSpan(s)
+		}
+	}
+}
+
+func (h *Heap) removePartial(c *central, s *span) {
+	if !s.partial {
+		return
+	}
+
+	for index, partial := range c.partial {
+		if partial == s {
+			c.partial = append(c.partial[:index], c.partial[index+1:]...)
+			break
+		}
+	}
+
+	s.partial = false
+}
+
+func (h *Heap) allocateLarge(size int) unsafe.Pointer {
+	s := h.allocateSpan((size+pageSize-1)/pageSize, 0)
+	s.size = len(s.memory)
+
+	h.largeObjects.Add(1)
+	h.largeBytes.Add(int64(s.size))
+	return unsafe.Pointer(unsafe.SliceData(s.memory))
+}
+
+func (h *Heap) freeLarge(s *span) {
+	h.largeObjects.Add(-1)
+	h.largeBytes.Add(-int64(s.size))
+	h.releaseSpan(s)
+}
@@ -0,0 +1,33 @@
+package slab
+
+// size classes and span sizes are taken from the Go runtime (internal/runtime/gc/sizeclasses.go),
+// class 0 is reserved for large objects that get a dedicated span
+
+const (
+	pageShift    = 13
+	pageSize     = 1 << pageShift
+	maxSmallSize = 32768
+	numClasses   = 68
+)
+
+var classSizes = [numClasses]int{0, 8, 16, 24, 32, 48, 64, 80, 96, 112, 128, 144, 160, 176, 192, 208, 224, 240, 256, 288, 320, 352, 384, 416, 448, 480, 512, 576, 640, 704, 768, 896, 1024, 1152, 1280, 1408, 1536, 1792, 2048, 2304, 2688, 3072, 3200, 3456, 4096, 4864, 5376, 6144, 6528, 6784, 6912, 8192, 9472, 9728, 10240, 10880, 12288, 13568, 14336, 16384, 18432, 19072, 20480, 21760, 24576, 27264, 28672, 32768}
+
+var classPages = [numClasses]int{0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 1, 2, 1, 2, 1, 3, 2, 3, 1, 3, 2, 3, 4, 5, 6, 1, 7, 6, 5, 4, 3, 5, 7, 2, 9, 7, 5, 8, 3, 10, 7, 4}
+
+// sizeToClass maps (size+7)/8 to the smallest class fitting the size
+var sizeToClass [maxSmallSize/8 + 1]uint8
+
+func init() {
+	class := 1
+	for index := 1; index < len(sizeToClass); index++ {
+		for classSizes[class] < index*8 {
+			class++
+		}
+
+		sizeToClass[index] = uint8(class)
+	}
+}
+
+func classOf(size int) int {
+	return int(sizeToClass[(size+7)/8])
+}
@@ -0,0 +1,232 @@
+package slab
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func TestSizeClasses(t *testing.T) {
+	tests := map[string]struct {
+		size  int
+		class int
+	}{
+		"smallest object":  {size: 1, class: 1},
+		"exact class size": {size: 16, class: 2},
+		"rounded up":       {size: 33, class: 5},
+		"allocations_size": {size: 33 + 33, class: 7},
+		"largest object":   {size: maxSmallSize, class: numClasses - 1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			class := classOf(test.size)
+			assert.Equal(t, test.class, class)
+			assert.GreaterOrEqual(t, classSizes[class], test.size)
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	heap := NewHeap(WithBatchSize(4))
+	cache := heap.NewCache()
+
+	pointer1, err := cache.Allocate(33)
+	require.NoError(t, err)
+	pointer2, err := cache.Allocate(40)
+	require.NoError(t, err)
+
+	// both sizes fall into the 48 bytes class and are taken from one span
+	assert.Equal(t, uintptr(48), uintptr(pointer2)-uintptr(pointer1))
+
+	*(*int64)(pointer1) = 100
+	assert.Equal(t, int64(100), *(*int64)(pointer1))
+
+	stats := heap.Stats()
+	require.Len(t, stats.Classes, 1)
+	assert.Equal(t, ClassStats{
+		Size:          48,
+		Spans:         1,
+		InUse:         2,
+		Free:          pageSize/48 - 2,
+		Fragmentation: 1 - 96.0/pageSize,
+	}, stats.Classes[0])
+
+	require.NoError(t, cache.Free(pointer1))
+	assert.Equal(t, 1, heap.Stats().Classes[0].InUse)
+
+	// freed objects are zeroed and reused first
+	pointer3, err := cache.Allocate(48)
+	require.NoError(t, err)
+	assert.Equal(t, pointer1, pointer3)
+	assert.Zero(t, *(*int64)(pointer3))
+
+	_, err = cache.Allocate(0)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+	assert.ErrorIs(t, cache.Free(unsafe.Add(pointer3, 8)), ErrInvalidPointer)
+	assert.ErrorIs(t, cache.Free(nil), ErrInvalidPointer)
+
+	// the span ends with 32 bytes that are aligned to the class size but aren't an object
+	tail := unsafe.Add(pointer1, pageSize/48*48)
+	assert.ErrorIs(t, cache.Free(tail), ErrInvalidPointer)
+
+	outside := new(int64)
+	assert.ErrorIs(t, cache.Free(unsafe.Pointer(outside)), ErrInvalidPointer)
+}
+
+func TestLargeObjects(t *testing.T) {
+	heap := NewHeap()
+	cache := heap.NewCache()
+
+	pointer, err := cache.Allocate(maxSmallSize + 1)
+	require.NoError(t, err)
+	assert.Zero(t, uintptr(pointer)%pageSize)
+
+	stats := heap.Stats()
+	assert.Equal(t, 1, stats.LargeObjects)
+	assert.Equal(t, 5*pageSize, stats.LargeBytes)
+
+	require.NoError(t, cache.Free(pointer))
+	assert.ErrorIs(t, cache.Free(pointer), ErrInvalidPointer)
+	assert.Zero(t, heap.Stats().LargeObjects)
+
+	// the span is reused by the next allocation of the same number of pages
+	reused, err := cache.Allocate(5 * pageSize)
+	require.NoError(t, err)
+	assert.Equal(t, pointer, reused)
+}
+
+func TestBatchRefillAndFlush(t *testing.T) {
+	const batch = 8
+
+	heap := NewHeap(WithBatchSize(batch))
+	cache := heap.NewCache()
+
+	pointer, err := cache.Allocate(8)
+	require.NoError(t, err)
+
+	class := classOf(8)
+	assert.Len(t, cache.objects[class], batch-1)
+
+	// a cache flushes half of its objects when it holds more than two batches
+	pointers := []unsafe.Pointer{pointer}
+	for range 3*batch - 1 {
+		pointer, err := cache.Allocate(8)
+		require.NoError(t, err)
+		pointers = append(pointers, pointer)
+	}
+
+	other := heap.NewCache()
+	for _, pointer := range pointers {
+		require.NoError(t, other.Free(pointer))
+		assert.LessOrEqual(t, len(other.objects[class]), 2*batch)
+	}
+
+	other.Flush()
+	cache.Flush()
+	assert.Empty(t, other.objects[class])
+
+	stats := heap.Stats()
+	require.Len(t, stats.Classes, 1)
+	assert.Zero(t, stats.Classes[0].InUse)
+	assert.Equal(t, pageSize/8, stats.Classes[0].Free)
+	assert.Equal(t, 1.0, stats.Classes[0].Fragmentation)
+}
+
+func TestSpanReuse(t *testing.T) {
+	heap := NewHeap(WithBatchSize(4))
+	cache := heap.NewCache()
+
+	// 4096 bytes objects fill a single page span with two objects
+	var pointers []unsafe.Pointer
+	for range 8 {
+		pointer, err := cache.Allocate(4096)
+		require.NoError(t, err)
+		pointers = append(pointers, pointer)
+	}
+
+	assert.Equal(t, 4, heap.Stats().Classes[0].Spans)
+
+	for _, pointer := range pointers {
+		require.NoError(t, cache.Free(pointer))
+	}
+
+	cache.Flush()
+
+	// empty spans go back to the heap except one kept for the next refill
+	assert.Equal(t, 1, heap.Stats().Classes[0].Spans)
+	assert.Len(t, heap.freeSpans[1], 3)
+
+	pointer, err := cache.Allocate(1024)
+	require.NoError(t, err)
+	assert.Contains(t, pointers, pointer)
+}
+
+func TestConcurrentCaches(t *testing.T) {
+	const workers = 8
+
+	heap := NewHeap()
+	exchange := make(chan unsafe.Pointer, 1024)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for worker := range workers {
+		go func() {
+			defer wg.Done()
+
+			cache := heap.NewCache()
+			defer cache.Flush()
+
+			random := rand.New(rand.NewSource(int64(worker)))
+			for range 10_000 {
+				size := 1 + random.Intn(1024)
+				pointer, err := cache.Allocate(size)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				data := unsafe.Slice((*byte)(pointer), size)
+				for index := range data {
+					if data[index] != 0 {
+						t.Errorf("allocated memory isn't zeroed")
+						return
+					}
+
+					data[index] = byte(worker)
+				}
+
+				// objects are freed by other workers too
+				select {
+				case exchange <- pointer:
+				default:
+					assert.NoError(t, cache.Free(pointer))
+				}
+
+				select {
+				case pointer := <-exchange:
+					assert.NoError(t, cache.Free(pointer))
+				default:
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(exchange)
+
+	cache := heap.NewCache()
+	for pointer := range exchange {
+		require.NoError(t, cache.Free(pointer))
+	}
+
+	cache.Flush()
+	for _, class := range heap.Stats().Classes {
+		assert.Zero(t, class.InUse)
+	}
+}
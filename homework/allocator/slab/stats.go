@@ -0,0 +1,48 @@
+package slab
+
+type ClassStats struct {
+	Size  int
+	Spans int
+	// InUse counts objects handed out by caches and not freed yet
+	InUse int
+	// Free counts objects of the class spans available in caches and central lists
+	Free int
+	// Fragmentation is the share of span memory of the class not used by live objects,
+	// it includes free objects and tails of spans too small for another object
+	Fragmentation float64
+}
+
+type Stats struct {
+	// Classes holds only classes that have spans
+	Classes      []ClassStats
+	LargeObjects int
+	LargeBytes   int
+}
+
+// Stats reads counters without stopping allocations, so it's approximate under concurrent use
+func (h *Heap) Stats() Stats {
+	stats := Stats{
+		LargeObjects: int(h.largeObjects.Load()),
+		LargeBytes:   int(h.largeBytes.Load()),
+	}
+
+	for class := 1; class < numClasses; class++ {
+		c := &h.centrals[class]
+		spans := int(c.spans.Load())
+		if spans == 0 {
+			continue
+		}
+
+		spanBytes := classPages[class] * pageSize
+		inUse := int(c.inUse.Load())
+		stats.Classes = append(stats.Classes, ClassStats{
+			Size:          classSizes[class],
+			Spans:         spans,
+			InUse:         inUse,
+			Free:          spans*(spanBytes/classSizes[class]) - inUse,
+			Fragmentation: 1 - float64(inUse*classSizes[class])/float64(spans*spanBytes),
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,287 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen     = errors.New("circuit breaker is open")
+	ErrTooManyRequests = errors.New("circuit breaker is half-open and busy")
+)
+
+type State uint8
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+type BreakerOption func(*Breaker)
+
+// WithWindow sets the number of last calls used for the failure rate, non-positive sizes are ignored
+func WithWindow(size int) BreakerOption {
+	return func(b *Breaker) {
+		if size > 0 {
+			b.window = newWindow(size)
+		}
+	}
+}
+
+// WithFailureRate opens the circuit when the rate of failures in the window reaches rate
+// and the window has at least minRequests calls, rates outside (0, 1] are ignored,
+// minRequests is clamped to the window size, otherwise the circuit could never open
+func WithFailureRate(rate float64, minRequests int) BreakerOption {
+	return func(b *Breaker) {
+		if rate > 0 && rate <= 1 {
+			b.failureRate = rate
+		}
+
+		b.minRequests = minRequests
+	}
+}
+
+// WithOpenTimeout sets how long the circuit stays open before probing the dependency,
+// a probe that doesn't report within the timeout counts as failed
+func WithOpenTimeout(timeout time.Duration) BreakerOption {
+	return func(b *Breaker) {
+		b.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenRequests sets the number of probes, all of them have to succeed to close the circuit,
+// non-positive numbers are ignored
+func WithHalfOpenRequests(requests int) BreakerOption {
+	return func(b *Breaker) {
+		if requests > 0 {
+			b.halfOpenRequests = requests
+		}
+	}
+}
+
+// WithIsFailure classifies errors, all errors except context cancellation are failures by default
+func WithIsFailure(isFailure func(error) bool) BreakerOption {
+	return func(b *Breaker) {
+		b.isFailure = isFailure
+	}
+}
+
+// WithOnStateChange runs under the breaker lock, so it must not call the breaker
+func WithOnStateChange(onStateChange func(from, to State)) BreakerOption {
+	return func(b *Breaker) {
+		b.onStateChange = onStateChange
+	}
+}
+
+func WithBreakerClock(now func() time.Time) BreakerOption {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+type BreakerMetrics struct {
+	State        State
+	FailureRate  float64
+	Successes    int
+	Failures     int
+	Rejected     int
+	StateChanges int
+}
+
+// Breaker stops calling a dependency that fails too often, gives it time to recover
+// and lets a few probe calls through before closing the circuit again
+type Breaker struct {
+	mutex            sync.Mutex
+	state            State
+	generation       uint64 // results of calls from previous states are ignored
+	window           *window
+	failureRate      float64
+	minRequests      int
+	openTimeout      time.Duration
+	openedAt         time.Time
+	halfOpenRequests int
+	probes           int
+	probeSuccesses   int
+	probedAt         time.Time // when the last probe was let through
+	isFailure        func(error) bool
+	onStateChange    func(from, to State)
+	now              func() time.Time
+	metrics          BreakerMetrics
+}
+
+func NewBreaker(options ...BreakerOption) *Breaker {
+	breaker := &Breaker{
+		window:           newWindow(100),
+		failureRate:      0.5,
+		minRequests:      20,
+		openTimeout:      30 * time.Second,
+		halfOpenRequests: 1,
+		isFailure: func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		},
+		now: time.Now,
+	}
+
+	for _, option := range options {
+		option(breaker)
+	}
+
+	breaker.minRequests = min(max(breaker.minRequests, 1), len(breaker.window.outcomes))
+	return breaker
+}
+
+// Execute counts a panic in action as a failure, the panic isn't recovered
+func (b *Breaker) Execute(ctx context.Context, action func(ctx context.Context) error) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			b.done(generation, true)
+		}
+	}()
+
+	err = action(ctx)
+	completed = true
+	b.done(generation, b.isFailure(err))
+	return err
+}
+
+// Allow reserves a call, done has to be called with the result of the call,
+// a probe whose done isn't called in time is counted as failed
+func (b *Breaker) Allow() (func(err error), error) {
+	generation, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) {
+		b.done(generation, b.isFailure(err))
+	}, nil
+}
+
+func (b *Breaker) allow() (uint64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probeAfterTimeout()
+
+	switch b.state {
+	case StateOpen:
+		b.metrics.Rejected++
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.halfOpenRequests {
+			b.metrics.Rejected++
+			return 0, ErrTooManyRequests
+		}
+
+		b.probes++
+		b.probedAt = b.now()
+	}
+
+	return b.generation, nil
+}
+
+func (b *Breaker) done(generation uint64, failure bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if failure {
+		b.metrics.Failures++
+	} else {
+		b.metrics.Successes++
+	}
+
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.window.add(failure)
+		if b.window.size >= b.minRequests && b.window.failureRate() >= b.failureRate {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if failure {
+			b.setState(StateOpen)
+			return
+		}
+
+		if b.probeSuccesses++; b.probeSuccesses >= b.halfOpenRequests {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// probeAfterTimeout moves an open circuit to half-open lazily, so no timers are needed,
+// and opens it again when probes are lost, otherwise every call would be rejected forever
+func (b *Breaker) probeAfterTimeout() {
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) >= b.openTimeout {
+			b.setState(StateHalfOpen)
+		}
+	case StateHalfOpen:
+		if b.probes > b.probeSuccesses && b.now().Sub(b.probedAt) >= b.openTimeout {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.metrics.StateChanges++
+
+	switch state {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateHalfOpen:
+		b.probes, b.probeSuccesses = 0, 0
+	case StateClosed:
+		b.window.reset()
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probeAfterTimeout()
+
+	return b.state
+}
+
+func (b *Breaker) Metrics() BreakerMetrics {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	metrics := b.metrics
+	metrics.State = b.state
+	metrics.FailureRate = b.window.failureRate()
+	return metrics
+}
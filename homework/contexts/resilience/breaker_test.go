@@ -0,0 +1,244 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+var errDependency = errors.New("dependency failed")
+
+func succeed(context.Context) error {
+	return nil
+}
+
+func fail(context.Context) error {
+	return errDependency
+}
+
+func TestWindow(t *testing.T) {
+	w := newWindow(4)
+	assert.Zero(t, w.failureRate())
+
+	for _, failure := range []bool{true, true, false, false, false, false} {
+		w.add(failure)
+	}
+
+	// the first two failures are out of the window
+	assert.Equal(t, 4, w.size)
+	assert.Zero(t, w.failureRate())
+
+	w.add(true)
+	assert.Equal(t, 0.25, w.failureRate())
+
+	w.reset()
+	assert.Zero(t, w.size)
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	var transitions []string
+	breaker := NewBreaker(
+		WithWindow(10),
+		WithFailureRate(0.5, 4),
+		WithOpenTimeout(time.Second),
+		WithHalfOpenRequests(2),
+		WithBreakerClock(func() time.Time { return now }),
+		WithOnStateChange(func(from, to State) {
+			transitions = append(transitions, from.String()+" -> "+to.String())
+		}),
+	)
+
+	// not enough calls to judge the dependency yet
+	for range 2 {
+		assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDependency)
+	}
+
+	assert.Equal(t, StateClosed, breaker.State())
+	require.NoError(t, breaker.Execute(context.Background(), succeed))
+	assert.Equal(t, StateClosed, breaker.State())
+	assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDependency)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// an open circuit doesn't call the dependency
+	called := false
+	err := breaker.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, called)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	// only two probes are allowed at once
+	done1, err := breaker.Allow()
+	require.NoError(t, err)
+	done2, err := breaker.Allow()
+	require.NoError(t, err)
+	_, err = breaker.Allow()
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	done1(nil)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	done2(nil)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	assert.Equal(t, []string{"closed -> open", "open -> half-open", "half-open -> closed"}, transitions)
+	assert.Equal(t, BreakerMetrics{
+		State:        StateClosed,
+		Successes:    3,
+		Failures:     3,
+		Rejected:     2,
+		StateChanges: 3,
+	}, breaker.Metrics())
+}
+
+func TestBreakerHalfOpenFailure(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(
+		WithFailureRate(1, 1),
+		WithOpenTimeout(time.Second),
+		WithBreakerClock(func() time.Time { return now }),
+	)
+
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	now = now.Add(time.Second)
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// the timeout starts over from the failed probe
+	now = now.Add(time.Second / 2)
+	assert.ErrorIs(t, breaker.Execute(context.Background(), succeed), ErrCircuitOpen)
+	now = now.Add(time.Second / 2)
+	assert.NoError(t, breaker.Execute(context.Background(), succeed))
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreakerIgnoresStaleResults(t *testing.T) {
+	breaker := NewBreaker(WithFailureRate(1, 1))
+
+	stale, err := breaker.Allow()
+	require.NoError(t, err)
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// a call started before the circuit opened doesn't change the state
+	stale(nil)
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.Equal(t, 1, breaker.Metrics().Successes)
+}
+
+func TestBreakerPanickingProbe(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(
+		WithFailureRate(1, 1),
+		WithOpenTimeout(time.Second),
+		WithBreakerClock(func() time.Time { return now }),
+	)
+
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	// the probe slot is released and the panic is counted as a failure
+	assert.Panics(t, func() {
+		_ = breaker.Execute(context.Background(), func(context.Context) error {
+			panic("probe panicked")
+		})
+	})
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.Equal(t, 2, breaker.Metrics().Failures)
+
+	now = now.Add(time.Second)
+	assert.NoError(t, breaker.Execute(context.Background(), succeed))
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreakerLostProbe(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(
+		WithFailureRate(1, 1),
+		WithOpenTimeout(time.Second),
+		WithBreakerClock(func() time.Time { return now }),
+	)
+
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	now = now.Add(time.Second)
+
+	lost, err := breaker.Allow()
+	require.NoError(t, err)
+	_, err = breaker.Allow()
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	// a probe that doesn't report in time opens the circuit again
+	now = now.Add(time.Second)
+	assert.Equal(t, StateOpen, breaker.State())
+	lost(nil)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	now = now.Add(time.Second)
+	assert.NoError(t, breaker.Execute(context.Background(), succeed))
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreakerOptionsValidation(t *testing.T) {
+	// a non-positive window keeps the default one
+	breaker := NewBreaker(WithWindow(0), WithFailureRate(1, 1))
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// minRequests larger than the window is clamped to its size
+	breaker = NewBreaker(WithWindow(10), WithHalfOpenRequests(0))
+	for range 9 {
+		assert.Error(t, breaker.Execute(context.Background(), fail))
+	}
+
+	assert.Equal(t, StateClosed, breaker.State())
+	assert.Error(t, breaker.Execute(context.Background(), fail))
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.Equal(t, 1, breaker.halfOpenRequests)
+
+	// rates outside (0, 1] keep the default one
+	for _, rate := range []float64{0, -0.5, 1.5} {
+		breaker = NewBreaker(WithWindow(4), WithFailureRate(rate, 2))
+		for range 2 {
+			require.NoError(t, breaker.Execute(context.Background(), succeed))
+		}
+
+		assert.Equal(t, StateClosed, breaker.State())
+		for range 2 {
+			assert.Error(t, breaker.Execute(context.Background(), fail))
+		}
+
+		assert.Equal(t, StateOpen, breaker.State(), "rate %v", rate)
+	}
+}
+
+func TestBreakerClassification(t *testing.T) {
+	breaker := NewBreaker(WithFailureRate(1, 1))
+
+	err := breaker.Execute(context.Background(), func(context.Context) error {
+		return context.Canceled
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	breaker = NewBreaker(WithFailureRate(1, 1), WithIsFailure(func(err error) bool {
+		return errors.Is(err, errDependency)
+	}))
+
+	assert.Error(t, breaker.Execute(context.Background(), func(context.Context) error {
+		return errors.New("bad request")
+	}))
+	assert.Equal(t, StateClosed, breaker.State())
+}
@@ -0,0 +1,113 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+type BulkheadOption func(*Bulkhead)
+
+// WithMaxWaiting rejects calls when too many of them wait, zero rejects
+// every call that can't start at once, waiting isn't limited by default
+func WithMaxWaiting(waiting int) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.maxWaiting = waiting
+	}
+}
+
+// WithOnSaturation is called when the bulkhead becomes full and when it has room again,
+// it runs under the bulkhead lock, so it must not call the bulkhead
+func WithOnSaturation(onSaturation func(saturated bool)) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.onSaturation = onSaturation
+	}
+}
+
+type BulkheadMetrics struct {
+	InFlight  int
+	Waiting   int
+	Completed int
+	Rejected  int
+}
+
+// Bulkhead caps the number of concurrent calls to a single dependency,
+// so a slow dependency can't take all goroutines and connections
+type Bulkhead struct {
+	semaphore    *semaphore
+	maxWaiting   int
+	onSaturation func(saturated bool)
+	metrics      BulkheadMetrics
+}
+
+// NewBulkhead lets at least one call through, a smaller limit would reject every call
+func NewBulkhead(limit int, options ...BulkheadOption) *Bulkhead {
+	bulkhead := &Bulkhead{
+		semaphore:  newSemaphore(max(limit, 1)),
+		maxWaiting: -1,
+	}
+
+	for _, option := range options {
+		option(bulkhead)
+	}
+
+	return bulkhead
+}
+
+// Execute waits for a free slot until the context is done
+func (b *Bulkhead) Execute(ctx context.Context, action func(ctx context.Context) error) error {
+	if err := b.Acquire(ctx); err != nil {
+		return err
+	}
+
+	defer b.Release()
+	return action(ctx)
+}
+
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	b.semaphore.condition.L.Lock()
+	defer b.semaphore.condition.L.Unlock()
+
+	full := b.semaphore.count >= b.semaphore.max
+	if full && b.maxWaiting >= 0 && b.metrics.Waiting >= b.maxWaiting {
+		b.metrics.Rejected++
+		return ErrBulkheadFull
+	}
+
+	b.metrics.Waiting++
+	err := b.semaphore.acquire(ctx)
+	b.metrics.Waiting--
+
+	if err != nil {
+		b.metrics.Rejected++
+		return err
+	}
+
+	b.metrics.InFlight++
+	if b.metrics.InFlight == b.semaphore.max && b.onSaturation != nil {
+		b.onSaturation(true)
+	}
+
+	return nil
+}
+
+func (b *Bulkhead) Release() {
+	b.semaphore.condition.L.Lock()
+	defer b.semaphore.condition.L.Unlock()
+
+	if b.metrics.InFlight == b.semaphore.max && b.onSaturation != nil {
+		b.onSaturation(false)
+	}
+
+	b.metrics.InFlight--
+	b.metrics.Completed++
+	b.semaphore.release()
+}
+
+func (b *Bulkhead) Metrics() BulkheadMetrics {
+	b.semaphore.condition.L.Lock()
+	defer b.semaphore.condition.L.Unlock()
+
+	return b.metrics
+}
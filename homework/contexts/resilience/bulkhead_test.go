@@ -0,0 +1,111 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkheadLimit(t *testing.T) {
+	const limit = 3
+
+	bulkhead := NewBulkhead(limit)
+
+	var inFlight, peak atomic.Int64
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := bulkhead.Execute(context.Background(), func(context.Context) error {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+
+				for {
+					previous := peak.Load()
+					if current <= previous || peak.CompareAndSwap(previous, current) {
+						break
+					}
+				}
+
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+	assert.LessOrEqual(t, peak.Load(), int64(limit))
+	assert.Equal(t, BulkheadMetrics{Completed: 50}, bulkhead.Metrics())
+}
+
+func TestBulkheadCancellation(t *testing.T) {
+	var saturations []bool
+	bulkhead := NewBulkhead(1, WithOnSaturation(func(saturated bool) {
+		saturations = append(saturations, saturated)
+	}))
+
+	require.NoError(t, bulkhead.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, bulkhead.Acquire(ctx), context.DeadlineExceeded)
+
+	// a waiter gets the slot after release
+	acquired := make(chan error)
+	go func() {
+		acquired <- bulkhead.Acquire(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return bulkhead.Metrics().Waiting == 1
+	}, time.Second, time.Millisecond)
+
+	bulkhead.Release()
+	require.NoError(t, <-acquired)
+	bulkhead.Release()
+
+	assert.Equal(t, BulkheadMetrics{Completed: 2, Rejected: 1}, bulkhead.Metrics())
+	assert.Equal(t, []bool{true, false, true, false}, saturations)
+}
+
+func TestBulkheadMaxWaiting(t *testing.T) {
+	bulkhead := NewBulkhead(1, WithMaxWaiting(0))
+	require.NoError(t, bulkhead.Acquire(context.Background()))
+
+	assert.ErrorIs(t, bulkhead.Acquire(context.Background()), ErrBulkheadFull)
+	assert.Equal(t, 1, bulkhead.Metrics().Rejected)
+
+	bulkhead.Release()
+	assert.NoError(t, bulkhead.Execute(context.Background(), succeed))
+}
+
+func TestBulkheadNonPositiveLimit(t *testing.T) {
+	bulkhead := NewBulkhead(0, WithMaxWaiting(0))
+	require.NoError(t, bulkhead.Acquire(context.Background()))
+	assert.ErrorIs(t, bulkhead.Acquire(context.Background()), ErrBulkheadFull)
+	bulkhead.Release()
+}
+
+func TestBulkheadWithBreaker(t *testing.T) {
+	bulkhead := NewBulkhead(2)
+	breaker := NewBreaker(WithFailureRate(0.5, 2))
+
+	call := func(action func(context.Context) error) error {
+		return bulkhead.Execute(context.Background(), func(ctx context.Context) error {
+			return breaker.Execute(ctx, action)
+		})
+	}
+
+	assert.NoError(t, call(succeed))
+	assert.ErrorIs(t, call(fail), errDependency)
+	assert.ErrorIs(t, call(succeed), ErrCircuitOpen)
+	assert.Equal(t, 3, bulkhead.Metrics().Completed)
+}
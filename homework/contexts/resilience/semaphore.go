@@ -0,0 +1,61 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+)
+
+// semaphore is the cond-based semaphore from the lessons, waiters
+// are woken up on cancellation with context.AfterFunc
+type semaphore struct {
+	count     int
+	max       int
+	condition *sync.Cond
+}
+
+func newSemaphore(limit int) *semaphore {
+	mutex := &sync.Mutex{}
+	return &semaphore{
+		max:       limit,
+		condition: sync.NewCond(mutex),
+	}
+}
+
+// acquire is called with the mutex locked and leaves it locked
+func (s *semaphore) acquire(ctx context.Context) error {
+	if s.count < s.max {
+		s.count++
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		// the lock guarantees the waiter is already in Wait
+		s.condition.L.Lock()
+		defer s.condition.L.Unlock()
+
+		s.condition.Broadcast()
+	})
+	defer stop()
+
+	for s.count >= s.max {
+		if ctx.Err() != nil {
+			// the waiter may have taken a signal meant for others
+			if s.count < s.max {
+				s.condition.Signal()
+			}
+
+			return context.Cause(ctx)
+		}
+
+		s.condition.Wait()
+	}
+
+	s.count++
+	return nil
+}
+
+// release is called with the mutex locked
+func (s *semaphore) release() {
+	s.count--
+	s.condition.Signal()
+}
@@ -0,0 +1,42 @@
+package resilience
+
+// window keeps outcomes of the last calls in a ring buffer
+type window struct {
+	outcomes []bool // true for failures
+	next     int
+	size     int
+	failures int
+}
+
+func newWindow(size int) *window {
+	return &window{outcomes: make([]bool, size)}
+}
+
+func (w *window) add(failure bool) {
+	if w.size == len(w.outcomes) {
+		if w.outcomes[w.next] {
+			w.failures--
+		}
+	} else {
+		w.size++
+	}
+
+	w.outcomes[w.next] = failure
+	w.next = (w.next + 1) % len(w.outcomes)
+	if failure {
+		w.failures++
+	}
+}
+
+func (w *window) failureRate() float64 {
+	if w.size == 0 {
+		return 0
+	}
+
+	return float64(w.failures) / float64(w.size)
+}
+
+func (w *window) reset() {
+	clear(w.outcomes)
+	w.next, w.size, w.failures = 0, 0, 0
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before the next attempt,
+// attempt starts from 1 and previous is 0 for the first delay
+type Backoff interface {
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+type BackoffFunc func(attempt int, previous time.Duration) time.Duration
+
+func (f BackoffFunc) Delay(attempt int, previous time.Duration) time.Duration {
+	return f(attempt, previous)
+}
+
+// Constant waits the same delay before every attempt
+func Constant(delay time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration {
+		return delay
+	})
+}
+
+// Exponential multiplies the initial delay by factor for every attempt up to max
+type Exponential struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+}
+
+func (e Exponential) Delay(attempt int, _ time.Duration) time.Duration {
+	delay := float64(e.Initial)
+	for range attempt - 1 {
+		delay *= e.Factor
+		if e.Max > 0 && delay >= float64(e.Max) {
+			return e.Max
+		}
+	}
+
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitter picks a random delay between base and three previous delays,
+// so clients retrying after the same failure spread out instead of retrying together
+type DecorrelatedJitter struct {
+	Base time.Duration
+	Max  time.Duration
+	Rand *rand.Rand // the global source when nil
+}
+
+func (d DecorrelatedJitter) Delay(_ int, previous time.Duration) time.Duration {
+	upper := max(3*previous, d.Base)
+	delay := d.Base
+	if span := int64(upper - d.Base); span > 0 {
+		delay += time.Duration(d.int64N(span + 1))
+	}
+
+	if d.Max > 0 {
+		delay = min(delay, d.Max)
+	}
+
+	return delay
+}
+
+func (d DecorrelatedJitter) int64N(n int64) int64 {
+	if d.Rand != nil {
+		return d.Rand.Int64N(n)
+	}
+
+	return rand.Int64N(n)
+}
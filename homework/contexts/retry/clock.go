@@ -0,0 +1,19 @@
+package retry
+
+import "time"
+
+// Clock lets tests run retries without sleeping
+type Clock interface {
+	Now() time.Time
+	After(delay time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(delay time.Duration) <-chan time.Time {
+	return time.After(delay)
+}
@@ -0,0 +1,62 @@
+package retry
+
+import "errors"
+
+var (
+	ErrMaxAttempts = errors.New("retry: max attempts reached")
+	ErrMaxElapsed  = errors.New("retry: max elapsed time reached")
+)
+
+// PermanentError stops retries, Do returns the error of action with the marker as is,
+// so IsPermanent works on the result and errors.Is and errors.As find the wrapped error
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// RetryableError is retried even when the default classification says otherwise
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PermanentError{Err: err}
+}
+
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RetryableError{Err: err}
+}
+
+// IsPermanent finds markers anywhere in the chain, so they survive fmt.Errorf("%w")
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable)
+}
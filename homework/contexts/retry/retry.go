@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Option func(*config)
+
+type config struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	backoff     Backoff
+	clock       Clock
+	retryIf     func(error) bool
+	onRetry     func(attempt int, err error, delay time.Duration)
+}
+
+// WithMaxAttempts limits the number of calls, zero means no limit
+func WithMaxAttempts(attempts int) Option {
+	return func(c *config) {
+		c.maxAttempts = attempts
+	}
+}
+
+// WithMaxElapsed stops retries when the next attempt would start after elapsed since the first one
+func WithMaxElapsed(elapsed time.Duration) Option {
+	return func(c *config) {
+		c.maxElapsed = elapsed
+	}
+}
+
+func WithBackoff(backoff Backoff) Option {
+	return func(c *config) {
+		c.backoff = backoff
+	}
+}
+
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithRetryIf classifies errors without markers, all of them are retried by default
+func WithRetryIf(retryIf func(error) bool) Option {
+	return func(c *config) {
+		c.retryIf = retryIf
+	}
+}
+
+// WithOnRetry is called before waiting for the next attempt, like for logging
+func WithOnRetry(onRetry func(attempt int, err error, delay time.Duration)) Option {
+	return func(c *config) {
+		c.onRetry = onRetry
+	}
+}
+
+func newConfig(options []Option) config {
+	c := config{
+		maxAttempts: 5,
+		backoff:     Exponential{Initial: 100 * time.Millisecond, Max: 10 * time.Second, Factor: 2},
+		clock:       realClock{},
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return c
+}
+
+func (c *config) retryable(err error) bool {
+	switch {
+	case IsPermanent(err):
+		return false
+	case IsRetryable(err):
+		return true
+	case c.retryIf != nil:
+		return c.retryIf(err)
+	default:
+		return true
+	}
+}
+
+// Do calls action until it succeeds, returns a permanent error or limits are reached,
+// the last error of action is wrapped into the returned error
+func Do(ctx context.Context, action func(ctx context.Context) error, options ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, action(ctx)
+	}, options...)
+
+	return err
+}
+
+func DoValue[T any](ctx context.Context, action func(ctx context.Context) (T, error), options ...Option) (T, error) {
+	c := newConfig(options)
+	start := c.clock.Now()
+
+	var zero T
+	if ctx.Err() != nil {
+		return zero, context.Cause(ctx)
+	}
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		value, err := action(ctx)
+		if err == nil {
+			return value, nil
+		}
+
+		if !c.retryable(err) {
+			return zero, err
+		}
+
+		if c.maxAttempts > 0 && attempt >= c.maxAttempts {
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrMaxAttempts, attempt, err)
+		}
+
+		delay = c.backoff.Delay(attempt, delay)
+		if c.maxElapsed > 0 && c.clock.Now().Add(delay).Sub(start) > c.maxElapsed {
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrMaxElapsed, attempt, err)
+		}
+
+		if c.onRetry != nil {
+			c.onRetry(attempt, err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-c.clock.After(delay):
+		}
+
+		// both channels may be ready, cancellation wins
+		if ctx.Err() != nil {
+			return zero, fmt.Errorf("%w: %w", context.Cause(ctx), err)
+		}
+	}
+}
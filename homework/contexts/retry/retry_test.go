@@ -0,0 +1,226 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+// fakeClock moves time forward instead of sleeping
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(delay time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(delay)
+	c.delays = append(c.delays, delay)
+
+	channel := make(chan time.Time, 1)
+	channel <- c.now
+	return channel
+}
+
+var errTemporary = errors.New("temporary")
+
+func failing(failures int, err error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+
+		return nil
+	}, &calls
+}
+
+func TestDo(t *testing.T) {
+	tests := map[string]struct {
+		failures int
+		err      error
+		options  []Option
+		calls    int
+		target   error
+	}{
+		"success": {
+			calls: 1,
+		},
+		"success after retries": {
+			failures: 2,
+			err:      errTemporary,
+			calls:    3,
+		},
+		"max attempts": {
+			failures: 10,
+			err:      errTemporary,
+			options:  []Option{WithMaxAttempts(3)},
+			calls:    3,
+			target:   ErrMaxAttempts,
+		},
+		"max elapsed time": {
+			failures: 10,
+			err:      errTemporary,
+			options:  []Option{WithMaxAttempts(0), WithMaxElapsed(time.Second), WithBackoff(Constant(300 * time.Millisecond))},
+			calls:    4,
+			target:   ErrMaxElapsed,
+		},
+		"permanent error": {
+			failures: 10,
+			err:      fmt.Errorf("wrapped: %w", Permanent(io.ErrUnexpectedEOF)),
+			calls:    1,
+			target:   io.ErrUnexpectedEOF,
+		},
+		"not retryable by classification": {
+			failures: 10,
+			err:      io.EOF,
+			options:  []Option{WithRetryIf(func(err error) bool { return !errors.Is(err, io.EOF) })},
+			calls:    1,
+			target:   io.EOF,
+		},
+		"retryable marker wins over classification": {
+			failures: 1,
+			err:      Retryable(io.EOF),
+			options:  []Option{WithRetryIf(func(err error) bool { return false })},
+			calls:    2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, calls := failing(test.failures, test.err)
+			options := append([]Option{WithClock(&fakeClock{})}, test.options...)
+
+			err := Do(context.Background(), action, options...)
+			assert.Equal(t, test.calls, *calls)
+			if test.target == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, test.target)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	clock := &fakeClock{}
+	action, _ := failing(10, errTemporary)
+
+	var attempts []int
+	err := Do(context.Background(), action,
+		WithClock(clock),
+		WithMaxAttempts(6),
+		WithBackoff(Exponential{Initial: time.Second, Max: 10 * time.Second, Factor: 2}),
+		WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			attempts = append(attempts, attempt)
+			assert.ErrorIs(t, err, errTemporary)
+		}),
+	)
+
+	assert.ErrorIs(t, err, ErrMaxAttempts)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, attempts)
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second,
+	}, clock.delays)
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	backoff := DecorrelatedJitter{
+		Base: 100 * time.Millisecond,
+		Max:  time.Second,
+		Rand: rand.New(rand.NewPCG(1, 2)),
+	}
+
+	var delay time.Duration
+	for attempt := 1; attempt <= 100; attempt++ {
+		next := backoff.Delay(attempt, delay)
+		assert.GreaterOrEqual(t, next, backoff.Base)
+		assert.LessOrEqual(t, next, max(backoff.Base, min(3*delay, backoff.Max)))
+		delay = next
+	}
+
+	assert.Equal(t, backoff.Base, DecorrelatedJitter{Base: backoff.Base}.Delay(1, 0))
+}
+
+func TestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errTemporary
+	}, WithClock(&fakeClock{}))
+
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, errTemporary)
+
+	// nothing is called with a done context
+	err = Do(ctx, func(context.Context) error {
+		calls++
+		return nil
+	})
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// a real wait is interrupted by the context
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err = Do(ctx, func(context.Context) error {
+		return errTemporary
+	}, WithBackoff(Constant(time.Hour)))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+func TestDoValue(t *testing.T) {
+	calls := 0
+	value, err := DoValue(context.Background(), func(context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", errTemporary
+		}
+
+		return "done", nil
+	}, WithClock(&fakeClock{}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "done", value)
+
+	value, err = DoValue(context.Background(), func(context.Context) (string, error) {
+		return "partial", Permanent(errTemporary)
+	})
+	assert.ErrorIs(t, err, errTemporary)
+	assert.True(t, IsPermanent(err))
+	assert.Empty(t, value)
+
+	assert.NoError(t, Permanent(nil))
+	assert.NoError(t, Retryable(nil))
+}
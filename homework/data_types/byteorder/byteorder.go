@@ -0,0 +1,130 @@
+package byteorder
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+type Integer interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr | ~int | ~uint
+}
+
+type Order uint8
+
+const (
+	LittleEndian Order = iota
+	BigEndian
+)
+
+func (o Order) String() string {
+	if o == BigEndian {
+		return "big endian"
+	}
+
+	return "little endian"
+}
+
+var nativeOrder = detectOrder()
+
+func detectOrder() Order {
+	var number int16 = 0x0001
+	if *(*int8)(unsafe.Pointer(&number)) == 1 {
+		return LittleEndian
+	}
+
+	return BigEndian
+}
+
+// NativeOrder returns the byte order of the host
+func NativeOrder() Order {
+	return nativeOrder
+}
+
+func IsLittleEndian() bool {
+	return nativeOrder == LittleEndian
+}
+
+func IsBigEndian() bool {
+	return nativeOrder == BigEndian
+}
+
+// Swap reverses bytes of a value, the width is taken from T
+func Swap[T Integer](value T) T {
+	switch unsafe.Sizeof(value) {
+	case 2:
+		return T(bits.ReverseBytes16(uint16(value)))
+	case 4:
+		return T(bits.ReverseBytes32(uint32(value)))
+	case 8:
+		return T(bits.ReverseBytes64(uint64(value)))
+	default:
+		return value
+	}
+}
+
+// ToOrder converts a value in the host order to the order,
+// it's the same as Swap for a different order and a no-op otherwise
+func ToOrder[T Integer](order Order, value T) T {
+	if order == nativeOrder {
+		return value
+	}
+
+	return Swap(value)
+}
+
+// FromOrder converts a value in the order to the host order
+func FromOrder[T Integer](order Order, value T) T {
+	return ToOrder(order, value)
+}
+
+func ToLittleEndian[T Integer](value T) T {
+	return ToOrder(LittleEndian, value)
+}
+
+func ToBigEndian[T Integer](value T) T {
+	return ToOrder(BigEndian, value)
+}
+
+// SwapSlice reverses bytes of every value in place, values are reinterpreted
+// as unsigned integers of the same width, so the loop doesn't depend on T
+func SwapSlice[T Integer](values []T) {
+	if len(values) == 0 {
+		return
+	}
+
+	pointer := unsafe.Pointer(unsafe.SliceData(values))
+	switch unsafe.Sizeof(values[0]) {
+	case 2:
+		swapSlice16(unsafe.Slice((*uint16)(pointer), len(values)))
+	case 4:
+		swapSlice32(unsafe.Slice((*uint32)(pointer), len(values)))
+	case 8:
+		swapSlice64(unsafe.Slice((*uint64)(pointer), len(values)))
+	}
+}
+
+// SliceToOrder converts values in the host order to the order in place
+func SliceToOrder[T Integer](order Order, values []T) {
+	if order != nativeOrder {
+		SwapSlice(values)
+	}
+}
+
+func swapSlice16(values []uint16) {
+	for index, value := range values {
+		values[index] = bits.ReverseBytes16(value)
+	}
+}
+
+func swapSlice32(values []uint32) {
+	for index, value := range values {
+		values[index] = bits.ReverseBytes32(value)
+	}
+}
+
+// swapSlice64 compiles to a single BSWAP per value on amd64 and REV on arm64
+func swapSlice64(values []uint64) {
+	for index, value := range values {
+		values[index] = bits.ReverseBytes64(value)
+	}
+}
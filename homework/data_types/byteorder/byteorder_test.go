@@ -0,0 +1,197 @@
+package byteorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func TestNativeOrder(t *testing.T) {
+	var number uint16 = 0x0102
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&number)), 2)
+
+	if IsLittleEndian() {
+		assert.Equal(t, []byte{0x02, 0x01}, data)
+		assert.Equal(t, LittleEndian, NativeOrder())
+	} else {
+		assert.Equal(t, []byte{0x01, 0x02}, data)
+		assert.True(t, IsBigEndian())
+	}
+}
+
+func TestSwap(t *testing.T) {
+	assert.Equal(t, uint8(0x01), Swap(uint8(0x01)))
+	assert.Equal(t, uint16(0x0201), Swap(uint16(0x0102)))
+	assert.Equal(t, uint32(0x04030201), Swap(uint32(0x01020304)))
+	assert.Equal(t, uint64(0x0807060504030201), Swap(uint64(0x0102030405060708)))
+	assert.Equal(t, int32(-0x01000001), Swap(int32(-2)))
+	assert.Equal(t, int16(0x00FF), Swap(int16(-0x0100)))
+
+	type ID uint32
+	assert.Equal(t, ID(0xFF000000), Swap(ID(0xFF)))
+
+	tests := map[string]struct {
+		order  Order
+		number uint32
+	}{
+		"little endian": {order: LittleEndian, number: 0x01020304},
+		"big endian":    {order: BigEndian, number: 0x01020304},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			converted := ToOrder(test.order, test.number)
+			data := unsafe.Slice((*byte)(unsafe.Pointer(&converted)), 4)
+
+			var expected []byte
+			if test.order == LittleEndian {
+				expected = binary.LittleEndian.AppendUint32(nil, test.number)
+			} else {
+				expected = binary.BigEndian.AppendUint32(nil, test.number)
+			}
+
+			assert.Equal(t, expected, data)
+			assert.Equal(t, test.number, FromOrder(test.order, converted))
+		})
+	}
+
+	assert.Equal(t, ToLittleEndian(uint16(0x0102)), Swap(ToBigEndian(uint16(0x0102))))
+}
+
+func TestSwapSlice(t *testing.T) {
+	values64 := []uint64{0x0102030405060708, 0, math.MaxUint64}
+	SwapSlice(values64)
+	assert.Equal(t, []uint64{0x0807060504030201, 0, math.MaxUint64}, values64)
+
+	values32 := []int32{0x01020304, -1}
+	SwapSlice(values32)
+	assert.Equal(t, []int32{0x04030201, -1}, values32)
+
+	values16 := []uint16{0x0102, 0x00FF}
+	SwapSlice(values16)
+	assert.Equal(t, []uint16{0x0201, 0xFF00}, values16)
+
+	values8 := []int8{1, 2}
+	SwapSlice(values8)
+	assert.Equal(t, []int8{1, 2}, values8)
+
+	SwapSlice([]uint64{})
+
+	values := []uint32{0x01020304}
+	SliceToOrder(NativeOrder(), values)
+	assert.Equal(t, []uint32{0x01020304}, values)
+}
+
+type Point struct {
+	X, Y float32
+}
+
+type Header struct {
+	Magic   [4]byte
+	Version uint16
+	Flags   bool
+	// padding isn't encoded
+	Length  int64
+	Origin  Point
+	Path    [3]Point
+	Checked [2]bool
+	_       [2]uint8
+	Scale   complex64
+	Offsets [2]int16
+}
+
+func TestCodec(t *testing.T) {
+	size, err := Size[Header]()
+	require.NoError(t, err)
+	assert.Equal(t, binary.Size(Header{}), size)
+	assert.Less(t, size, int(unsafe.Sizeof(Header{})))
+
+	header := Header{
+		Magic:   [4]byte{'G', 'O', 'B', 'O'},
+		Version: 3,
+		Flags:   true,
+		Length:  -2,
+		Origin:  Point{X: 1.5, Y: -2},
+		Path:    [3]Point{{X: 1}, {Y: 2}, {X: math.MaxFloat32}},
+		Checked: [2]bool{false, true},
+		Scale:   complex(1, -1),
+		Offsets: [2]int16{-1, 0x0102},
+	}
+
+	for _, test := range []struct {
+		order  Order
+		stdlib binary.ByteOrder
+	}{
+		{order: LittleEndian, stdlib: binary.LittleEndian},
+		{order: BigEndian, stdlib: binary.BigEndian},
+	} {
+		t.Run(test.order.String(), func(t *testing.T) {
+			data, err := Marshal(test.order, &header)
+			require.NoError(t, err)
+
+			var expected bytes.Buffer
+			require.NoError(t, binary.Write(&expected, test.stdlib, &header))
+			assert.Equal(t, expected.Bytes(), data)
+
+			var decoded Header
+			require.NoError(t, Decode(test.order, data, &decoded))
+			assert.Equal(t, header, decoded)
+
+			var stream bytes.Buffer
+			require.NoError(t, Write(&stream, test.order, &header))
+			require.NoError(t, Write(&stream, test.order, &Point{X: 7}))
+
+			var point Point
+			require.NoError(t, Read(&stream, test.order, &decoded))
+			require.NoError(t, Read(&stream, test.order, &point))
+			assert.Equal(t, header, decoded)
+			assert.Equal(t, Point{X: 7}, point)
+			assert.Error(t, Read(&stream, test.order, &point))
+		})
+	}
+
+	data := make([]byte, size-1)
+	assert.ErrorIs(t, Encode(LittleEndian, data, &header), ErrShortBuffer)
+	assert.ErrorIs(t, Decode(LittleEndian, data, &header), ErrShortBuffer)
+}
+
+func TestUnsupportedTypes(t *testing.T) {
+	type withInt struct {
+		Count int
+	}
+
+	type withString struct {
+		Name string
+	}
+
+	type nested struct {
+		Points [2]struct {
+			Next *nested
+		}
+	}
+
+	_, err := Size[withInt]()
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = Marshal(LittleEndian, &withString{})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.ErrorIs(t, Decode(LittleEndian, nil, &nested{}), ErrUnsupportedType)
+
+	// errors are cached like layouts
+	_, err = Size[withInt]()
+	assert.ErrorContains(t, err, "Count")
+}
+
+func TestLayoutMerging(t *testing.T) {
+	l, err := layoutOf[Header]()
+	require.NoError(t, err)
+
+	// magic, version, flags, length, floats, checked, blank, scale, offsets
+	assert.Len(t, l.fields, 9)
+}
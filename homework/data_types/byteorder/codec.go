@@ -0,0 +1,294 @@
+package byteorder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+var (
+	ErrUnsupportedType = errors.New("type isn't of fixed size")
+	ErrShortBuffer     = errors.New("buffer is too short")
+)
+
+type fieldKind uint8
+
+const (
+	kindBytes fieldKind = iota // copied as is
+	kindBool
+	kindNumber // swapped when orders differ
+	kindBlank  // "_" fields are written as zeros and skipped on decoding
+)
+
+// field is a run of count values of size bytes at offset in memory,
+// fields are encoded one after another without padding
+type field struct {
+	offset uintptr
+	size   uintptr
+	count  int
+	kind   fieldKind
+}
+
+type layout struct {
+	fields []field
+	size   int
+}
+
+var layouts sync.Map // reflect.Type -> *layout or error
+
+func layoutOf[T any]() (*layout, error) {
+	t := reflect.TypeFor[T]()
+	if cached, ok := layouts.Load(t); ok {
+		if err, ok := cached.(error); ok {
+			return nil, err
+		}
+
+		return cached.(*layout), nil
+	}
+
+	l := &layout{}
+	if err := l.add(t, 0, false); err != nil {
+		err = fmt.Errorf("%w: %s", err, t)
+		layouts.Store(t, err)
+		return nil, err
+	}
+
+	for _, f := range l.fields {
+		l.size += int(f.size) * f.count
+	}
+
+	layouts.Store(t, l)
+	return l, nil
+}
+
+func (l *layout) add(t reflect.Type, offset uintptr, blank bool) error {
+	switch t.Kind() {
+	case reflect.Bool:
+		l.append(field{offset: offset, size: 1, count: 1, kind: kindBool}, blank)
+	case reflect.Int8, reflect.Uint8:
+		l.append(field{offset: offset, size: 1, count: 1, kind: kindBytes}, blank)
+	case reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		l.append(field{offset: offset, size: t.Size(), count: 1, kind: kindNumber}, blank)
+	case reflect.Complex64, reflect.Complex128:
+		// real and imaginary parts are swapped separately
+		l.append(field{offset: offset, size: t.Size() / 2, count: 2, kind: kindNumber}, blank)
+	case reflect.Array:
+		return l.addArray(t, offset, blank)
+	case reflect.Struct:
+		for index := 0; index < t.NumField(); index++ {
+			structField := t.Field(index)
+			if err := l.add(structField.Type, offset+structField.Offset, blank || structField.Name == "_"); err != nil {
+				return fmt.Errorf("field %s: %w", structField.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+
+	return nil
+}
+
+func (l *layout) addArray(t reflect.Type, offset uintptr, blank bool) error {
+	if t.Len() == 0 {
+		return nil
+	}
+
+	// arrays of numbers are a single field, other arrays are unrolled
+	element := &layout{}
+	if err := element.add(t.Elem(), 0, blank); err != nil {
+		return err
+	}
+
+	if len(element.fields) == 1 && element.fields[0].count == 1 {
+		f := element.fields[0]
+		f.offset += offset
+		f.count = t.Len()
+		l.append(f, blank)
+		return nil
+	}
+
+	for index := 0; index < t.Len(); index++ {
+		if err := l.add(t.Elem(), offset+uintptr(index)*t.Elem().Size(), blank); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// append merges a field with the previous one when they are adjacent
+// in memory, so runs of bytes and numbers are copied at once
+func (l *layout) append(f field, blank bool) {
+	if blank {
+		f.kind = kindBlank
+	}
+
+	if len(l.fields) > 0 {
+		last := &l.fields[len(l.fields)-1]
+		mergeable := last.kind == f.kind && f.kind != kindBool
+		sameSize := last.size == f.size || f.kind != kindNumber
+		if mergeable && sameSize && last.offset+last.size*uintptr(last.count) == f.offset {
+			if f.kind == kindNumber {
+				last.count += f.count
+			} else {
+				last.size, last.count = last.size*uintptr(last.count)+f.size*uintptr(f.count), 1
+			}
+
+			return
+		}
+	}
+
+	l.fields = append(l.fields, f)
+}
+
+// Size returns the encoded size of T, it's smaller than
+// unsafe.Sizeof when T has padding between fields
+func Size[T any]() (int, error) {
+	l, err := layoutOf[T]()
+	if err != nil {
+		return 0, err
+	}
+
+	return l.size, nil
+}
+
+func Marshal[T any](order Order, value *T) ([]byte, error) {
+	l, err := layoutOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, l.size)
+	l.encode(order, data, unsafe.Pointer(value))
+	return data, nil
+}
+
+// Encode writes a value into the beginning of data
+func Encode[T any](order Order, data []byte, value *T) error {
+	l, err := layoutOf[T]()
+	if err != nil {
+		return err
+	}
+
+	if len(data) < l.size {
+		return ErrShortBuffer
+	}
+
+	l.encode(order, data, unsafe.Pointer(value))
+	return nil
+}
+
+// Decode reads a value from the beginning of data
+func Decode[T any](order Order, data []byte, value *T) error {
+	l, err := layoutOf[T]()
+	if err != nil {
+		return err
+	}
+
+	if len(data) < l.size {
+		return ErrShortBuffer
+	}
+
+	l.decode(order, data, unsafe.Pointer(value))
+	return nil
+}
+
+func Write[T any](writer io.Writer, order Order, value *T) error {
+	data, err := Marshal(order, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
+
+func Read[T any](reader io.Reader, order Order, value *T) error {
+	l, err := layoutOf[T]()
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, l.size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return err
+	}
+
+	l.decode(order, data, unsafe.Pointer(value))
+	return nil
+}
+
+func (l *layout) encode(order Order, data []byte, pointer unsafe.Pointer) {
+	position := 0
+	for _, f := range l.fields {
+		length := int(f.size) * f.count
+		target := data[position : position+length]
+		position += length
+
+		source := unsafe.Slice((*byte)(unsafe.Add(pointer, f.offset)), length)
+		switch f.kind {
+		case kindBlank:
+			clear(target)
+		case kindBool:
+			for index, value := range source {
+				target[index] = 0
+				if value != 0 {
+					target[index] = 1
+				}
+			}
+		default:
+			copy(target, source)
+			if f.kind == kindNumber && order != nativeOrder {
+				swapBytes(target, f.size)
+			}
+		}
+	}
+}
+
+func (l *layout) decode(order Order, data []byte, pointer unsafe.Pointer) {
+	position := 0
+	for _, f := range l.fields {
+		length := int(f.size) * f.count
+		source := data[position : position+length]
+		position += length
+
+		target := unsafe.Slice((*byte)(unsafe.Add(pointer, f.offset)), length)
+		switch f.kind {
+		case kindBlank:
+		case kindBool:
+			for index, value := range source {
+				*(*bool)(unsafe.Pointer(&target[index])) = value != 0
+			}
+		default:
+			copy(target, source)
+			if f.kind == kindNumber && order != nativeOrder {
+				swapBytes(target, f.size)
+			}
+		}
+	}
+}
+
+// swapBytes swaps every size bytes of data in place, data may be unaligned,
+// so values are loaded with encoding/binary which compiles to a single load and swap
+func swapBytes(data []byte, size uintptr) {
+	switch size {
+	case 2:
+		for start := 0; start < len(data); start += 2 {
+			binary.LittleEndian.PutUint16(data[start:], binary.BigEndian.Uint16(data[start:]))
+		}
+	case 4:
+		for start := 0; start < len(data); start += 4 {
+			binary.LittleEndian.PutUint32(data[start:], binary.BigEndian.Uint32(data[start:]))
+		}
+	case 8:
+		for start := 0; start < len(data); start += 8 {
+			binary.LittleEndian.PutUint64(data[start:], binary.BigEndian.Uint64(data[start:]))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package byteorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// go test -bench=. -benchmem
+
+var Sink []byte
+
+func BenchmarkSwapLoop(b *testing.B) {
+	values := make([]uint64, 1<<16)
+	for i := 0; i < b.N; i++ {
+		for index, value := range values {
+			values[index] = Swap(value)
+		}
+	}
+}
+
+func BenchmarkSwapSlice(b *testing.B) {
+	values := make([]uint64, 1<<16)
+	for i := 0; i < b.N; i++ {
+		SwapSlice(values)
+	}
+}
+
+func BenchmarkBinaryWrite(b *testing.B) {
+	var header Header
+	var buffer bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		_ = binary.Write(&buffer, binary.BigEndian, &header)
+		Sink = buffer.Bytes()
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	var header Header
+	data := make([]byte, binary.Size(header))
+	for i := 0; i < b.N; i++ {
+		_ = Encode(BigEndian, data, &header)
+		Sink = data
+	}
+}
@@ -0,0 +1,134 @@
+package checked
+
+import (
+	"errors"
+	"unsafe"
+)
+
+var (
+	ErrOverflow       = errors.New("integer overflow")
+	ErrDivisionByZero = errors.New("integer division by zero")
+)
+
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Integer interface {
+	Signed | Unsigned
+}
+
+func isSigned[T Integer]() bool {
+	var zero T
+	return zero-1 < zero
+}
+
+// Max and Min return limits of T, like math.MaxInt32 for int32
+func Max[T Integer]() T {
+	var zero T
+	width := unsafe.Sizeof(zero) * 8
+	if isSigned[T]() {
+		return T(^uint64(0) >> (65 - width))
+	}
+
+	return ^zero
+}
+
+func Min[T Integer]() T {
+	if isSigned[T]() {
+		return ^Max[T]()
+	}
+
+	return 0
+}
+
+// Add returns the sum and false when it doesn't fit into T
+func Add[T Integer](lhs, rhs T) (T, bool) {
+	result := lhs + rhs
+	if isSigned[T]() {
+		return result, rhs > 0 && result > lhs || rhs < 0 && result < lhs || rhs == 0
+	}
+
+	return result, result >= lhs
+}
+
+func Sub[T Integer](lhs, rhs T) (T, bool) {
+	result := lhs - rhs
+	if isSigned[T]() {
+		return result, rhs > 0 && result < lhs || rhs < 0 && result > lhs || rhs == 0
+	}
+
+	return result, lhs >= rhs
+}
+
+func Mul[T Integer](lhs, rhs T) (T, bool) {
+	if lhs == 0 || rhs == 0 {
+		return 0, true
+	}
+
+	result := lhs * rhs
+	if isSigned[T]() && (lhs == ^T(0) && rhs == Min[T]() || rhs == ^T(0) && lhs == Min[T]()) {
+		// the only case where result / rhs == lhs in spite of overflow
+		return result, false
+	}
+
+	return result, result/rhs == lhs
+}
+
+// Div returns false for division by zero and for Min / -1 of signed types
+func Div[T Integer](lhs, rhs T) (T, bool) {
+	if rhs == 0 {
+		return 0, false
+	}
+
+	if isSigned[T]() && lhs == Min[T]() && rhs == ^T(0) {
+		return lhs, false
+	}
+
+	return lhs / rhs, true
+}
+
+// Neg returns false for Min of signed types and for anything except zero of unsigned
+func Neg[T Integer](value T) (T, bool) {
+	if isSigned[T]() {
+		return -value, value != Min[T]()
+	}
+
+	return -value, value == 0
+}
+
+func TryAdd[T Integer](lhs, rhs T) (T, error) {
+	return orOverflow(Add(lhs, rhs))
+}
+
+func TrySub[T Integer](lhs, rhs T) (T, error) {
+	return orOverflow(Sub(lhs, rhs))
+}
+
+func TryMul[T Integer](lhs, rhs T) (T, error) {
+	return orOverflow(Mul(lhs, rhs))
+}
+
+func TryDiv[T Integer](lhs, rhs T) (T, error) {
+	if rhs == 0 {
+		return 0, ErrDivisionByZero
+	}
+
+	return orOverflow(Div(lhs, rhs))
+}
+
+func TryNeg[T Integer](value T) (T, error) {
+	return orOverflow(Neg(value))
+}
+
+func orOverflow[T Integer](result T, ok bool) (T, error) {
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return result, nil
+}
@@ -0,0 +1,202 @@
+package checked
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v ./...
+
+func TestLimits(t *testing.T) {
+	assert.Equal(t, int8(math.MaxInt8), Max[int8]())
+	assert.Equal(t, int8(math.MinInt8), Min[int8]())
+	assert.Equal(t, int32(math.MaxInt32), Max[int32]())
+	assert.Equal(t, int64(math.MinInt64), Min[int64]())
+	assert.Equal(t, math.MaxInt, Max[int]())
+	assert.Equal(t, uint16(math.MaxUint16), Max[uint16]())
+	assert.Equal(t, uint64(math.MaxUint64), Max[uint64]())
+	assert.Zero(t, Min[uint]())
+
+	type Cents int32
+	assert.Equal(t, Cents(math.MaxInt32), Max[Cents]())
+}
+
+func testExhaustive[T Integer](t *testing.T) {
+	t.Helper()
+
+	minValue, maxValue := int(Min[T]()), int(Max[T]())
+	operations := map[string]struct {
+		checked    func(lhs, rhs T) (T, bool)
+		saturating func(lhs, rhs T) T
+		wrapping   func(lhs, rhs T) T
+		exact      func(lhs, rhs int) (int, bool)
+	}{
+		"add": {
+			checked: Add[T], saturating: SaturatingAdd[T], wrapping: WrappingAdd[T],
+			exact: func(lhs, rhs int) (int, bool) { return lhs + rhs, true },
+		},
+		"sub": {
+			checked: Sub[T], saturating: SaturatingSub[T], wrapping: WrappingSub[T],
+			exact: func(lhs, rhs int) (int, bool) { return lhs - rhs, true },
+		},
+		"mul": {
+			checked: Mul[T], saturating: SaturatingMul[T], wrapping: WrappingMul[T],
+			exact: func(lhs, rhs int) (int, bool) { return lhs * rhs, true },
+		},
+		"div": {
+			checked: Div[T], saturating: SaturatingDiv[T], wrapping: WrappingDiv[T],
+			exact: func(lhs, rhs int) (int, bool) {
+				if rhs == 0 {
+					return 0, false
+				}
+
+				return lhs / rhs, true
+			},
+		},
+	}
+
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			for lhs := minValue; lhs <= maxValue; lhs++ {
+				for rhs := minValue; rhs <= maxValue; rhs++ {
+					exact, defined := operation.exact(lhs, rhs)
+					fits := defined && exact >= minValue && exact <= maxValue
+
+					result, ok := operation.checked(T(lhs), T(rhs))
+					if ok != fits || ok && int(result) != exact {
+						t.Fatalf("%d %s %d = %d, %t", lhs, name, rhs, result, ok)
+					}
+
+					if !defined {
+						continue
+					}
+
+					if wrapped := operation.wrapping(T(lhs), T(rhs)); wrapped != T(exact) {
+						t.Fatalf("wrapping %d %s %d = %d", lhs, name, rhs, wrapped)
+					}
+
+					saturated := int(operation.saturating(T(lhs), T(rhs)))
+					if saturated != min(max(exact, minValue), maxValue) {
+						t.Fatalf("saturating %d %s %d = %d", lhs, name, rhs, saturated)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("neg", func(t *testing.T) {
+		for value := minValue; value <= maxValue; value++ {
+			fits := -value >= minValue && -value <= maxValue
+			result, ok := Neg(T(value))
+			assert.Equal(t, fits, ok, value)
+			if ok {
+				assert.Equal(t, -value, int(result))
+			}
+
+			assert.Equal(t, min(max(-value, minValue), maxValue), int(SaturatingNeg(T(value))))
+			assert.Equal(t, T(-value), WrappingNeg(T(value)))
+		}
+	})
+}
+
+func TestExhaustive8(t *testing.T) {
+	t.Run("int8", testExhaustive[int8])
+	t.Run("uint8", testExhaustive[uint8])
+}
+
+func TestConvert(t *testing.T) {
+	for value := math.MinInt16; value <= math.MaxUint16; value++ {
+		signed, ok := Convert[int8](value)
+		fits := value >= math.MinInt8 && value <= math.MaxInt8
+		if ok != fits || ok && int(signed) != value {
+			t.Fatalf("int8(%d) = %d, %t", value, signed, ok)
+		}
+
+		unsigned, ok := Convert[uint8](int16(value))
+		fits = value >= 0 && value <= math.MaxUint8
+		if ok != fits || ok && int(unsigned) != value {
+			t.Fatalf("uint8(%d) = %d, %t", int16(value), unsigned, ok)
+		}
+
+		clamped := int(SaturatingConvert[int8](value))
+		if clamped != min(max(value, math.MinInt8), math.MaxInt8) {
+			t.Fatalf("saturating int8(%d) = %d", value, clamped)
+		}
+	}
+
+	tests := map[string]struct {
+		convert func() (int64, bool)
+		result  int64
+		ok      bool
+	}{
+		"max uint64": {
+			convert: func() (int64, bool) { return Convert[int64](uint64(math.MaxUint64)) },
+			ok:      false,
+		},
+		"negative to uint32": {
+			convert: func() (int64, bool) {
+				result, ok := Convert[uint32](int64(-1))
+				return int64(result), ok
+			},
+			result: math.MaxUint32,
+			ok:     false,
+		},
+		"uint32 to int32": {
+			convert: func() (int64, bool) {
+				result, ok := Convert[int32](uint32(math.MaxInt32 + 1))
+				return int64(result), ok
+			},
+			result: math.MinInt32,
+			ok:     false,
+		},
+		"int64 to int32": {
+			convert: func() (int64, bool) {
+				result, ok := Convert[int32](int64(math.MinInt32))
+				return int64(result), ok
+			},
+			result: math.MinInt32,
+			ok:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, ok := test.convert()
+			assert.Equal(t, test.ok, ok)
+			if test.result != 0 {
+				assert.Equal(t, test.result, result)
+			}
+		})
+	}
+
+	assert.Equal(t, uint8(0), SaturatingConvert[uint8](-5))
+	assert.Equal(t, uint8(0x34), WrappingConvert[uint8](0x1234))
+}
+
+func TestErrors(t *testing.T) {
+	balance := int32(math.MaxInt32 - 10)
+
+	_, err := TryAdd(balance, 11)
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	result, err := TryAdd(balance, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), result)
+
+	_, err = TrySub[uint32](1, 2)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = TryMul[int32](1<<16, 1<<15)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = TryDiv[int32](1, 0)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+	_, err = TryDiv[int32](math.MinInt32, -1)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = TryNeg[int32](math.MinInt32)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = TryConvert[int32](int64(math.MaxInt32 + 1))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	assert.PanicsWithValue(t, ErrDivisionByZero, func() { SaturatingDiv[int8](1, 0) })
+}
@@ -0,0 +1,36 @@
+package checked
+
+// Convert returns false when the value doesn't fit into To,
+// a value fits when it survives the conversion back and keeps its sign
+func Convert[To, From Integer](value From) (To, bool) {
+	result := To(value)
+	return result, From(result) == value && (result < 0) == (value < 0)
+}
+
+func TryConvert[To, From Integer](value From) (To, error) {
+	result, ok := Convert[To](value)
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return result, nil
+}
+
+// SaturatingConvert clamps the value to limits of To
+func SaturatingConvert[To, From Integer](value From) To {
+	result, ok := Convert[To](value)
+	if ok {
+		return result
+	}
+
+	if value < 0 {
+		return Min[To]()
+	}
+
+	return Max[To]()
+}
+
+// WrappingConvert keeps low bits of the value like a plain conversion
+func WrappingConvert[To, From Integer](value From) To {
+	return To(value)
+}
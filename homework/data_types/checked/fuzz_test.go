@@ -0,0 +1,100 @@
+package checked
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// go test -fuzz=FuzzInt64 -fuzztime=30s
+
+type bigOperation func(result, lhs, rhs *big.Int) *big.Int
+
+var bigOperations = []struct {
+	name string
+	big  bigOperation
+}{
+	{name: "add", big: (*big.Int).Add},
+	{name: "sub", big: (*big.Int).Sub},
+	{name: "mul", big: (*big.Int).Mul},
+	{name: "div", big: (*big.Int).Quo},
+}
+
+func checkAgainstBig[T Integer](t *testing.T, lhs, rhs T, toBig func(T) *big.Int) {
+	minValue, maxValue := toBig(Min[T]()), toBig(Max[T]())
+	operations := []struct {
+		checked    func(lhs, rhs T) (T, bool)
+		saturating func(lhs, rhs T) T
+	}{
+		{checked: Add[T], saturating: SaturatingAdd[T]},
+		{checked: Sub[T], saturating: SaturatingSub[T]},
+		{checked: Mul[T], saturating: SaturatingMul[T]},
+		{checked: Div[T], saturating: SaturatingDiv[T]},
+	}
+
+	for index, operation := range operations {
+		name := bigOperations[index].name
+		if name == "div" && rhs == 0 {
+			if _, ok := operation.checked(lhs, rhs); ok {
+				t.Fatalf("%d div 0 succeeded", lhs)
+			}
+
+			continue
+		}
+
+		exact := bigOperations[index].big(new(big.Int), toBig(lhs), toBig(rhs))
+		fits := exact.Cmp(minValue) >= 0 && exact.Cmp(maxValue) <= 0
+
+		result, ok := operation.checked(lhs, rhs)
+		if ok != fits || ok && toBig(result).Cmp(exact) != 0 {
+			t.Fatalf("%d %s %d = %d, %t, expected %s", lhs, name, rhs, result, ok, exact)
+		}
+
+		expected := exact
+		if exact.Cmp(minValue) < 0 {
+			expected = minValue
+		} else if exact.Cmp(maxValue) > 0 {
+			expected = maxValue
+		}
+
+		if saturated := operation.saturating(lhs, rhs); toBig(saturated).Cmp(expected) != 0 {
+			t.Fatalf("saturating %d %s %d = %d, expected %s", lhs, name, rhs, saturated, expected)
+		}
+	}
+}
+
+func FuzzInt64(f *testing.F) {
+	f.Add(int64(0), int64(0))
+	f.Add(int64(math.MaxInt64), int64(1))
+	f.Add(int64(math.MinInt64), int64(-1))
+	f.Add(int64(math.MinInt64), int64(math.MinInt64))
+	f.Add(int64(1<<32), int64(1<<31))
+	f.Add(int64(-3037000500), int64(3037000500))
+
+	f.Fuzz(func(t *testing.T, lhs, rhs int64) {
+		checkAgainstBig(t, lhs, rhs, big.NewInt)
+
+		converted, ok := Convert[uint64](lhs)
+		if ok != (lhs >= 0) || ok && int64(converted) != lhs {
+			t.Fatalf("uint64(%d) = %d, %t", lhs, converted, ok)
+		}
+	})
+}
+
+func FuzzUint64(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(math.MaxUint64), uint64(1))
+	f.Add(uint64(1<<32), uint64(1<<32))
+	f.Add(uint64(math.MaxUint64), uint64(math.MaxUint64))
+
+	f.Fuzz(func(t *testing.T, lhs, rhs uint64) {
+		checkAgainstBig(t, lhs, rhs, func(value uint64) *big.Int {
+			return new(big.Int).SetUint64(value)
+		})
+
+		converted, ok := Convert[int64](lhs)
+		if ok != (lhs <= math.MaxInt64) || ok && uint64(converted) != lhs {
+			t.Fatalf("int64(%d) = %d, %t", lhs, converted, ok)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package checked
+
+// saturating variants clamp results to limits of T instead of failing
+
+func SaturatingAdd[T Integer](lhs, rhs T) T {
+	result, ok := Add(lhs, rhs)
+	if ok {
+		return result
+	}
+
+	if rhs < 0 {
+		return Min[T]()
+	}
+
+	return Max[T]()
+}
+
+func SaturatingSub[T Integer](lhs, rhs T) T {
+	result, ok := Sub(lhs, rhs)
+	if ok {
+		return result
+	}
+
+	if rhs > 0 {
+		return Min[T]()
+	}
+
+	return Max[T]()
+}
+
+func SaturatingMul[T Integer](lhs, rhs T) T {
+	result, ok := Mul(lhs, rhs)
+	if ok {
+		return result
+	}
+
+	if (lhs < 0) != (rhs < 0) {
+		return Min[T]()
+	}
+
+	return Max[T]()
+}
+
+// SaturatingDiv panics on division by zero like the / operator
+func SaturatingDiv[T Integer](lhs, rhs T) T {
+	result, ok := Div(lhs, rhs)
+	if ok {
+		return result
+	}
+
+	if rhs == 0 {
+		panic(ErrDivisionByZero)
+	}
+
+	return Max[T]()
+}
+
+func SaturatingNeg[T Integer](value T) T {
+	result, ok := Neg(value)
+	if ok {
+		return result
+	}
+
+	if isSigned[T]() {
+		return Max[T]()
+	}
+
+	return 0
+}
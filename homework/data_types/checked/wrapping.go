@@ -0,0 +1,25 @@
+package checked
+
+// wrapping variants are the Go operators, they exist to make
+// the intent explicit where overflow is expected, like in hashes
+
+func WrappingAdd[T Integer](lhs, rhs T) T {
+	return lhs + rhs
+}
+
+func WrappingSub[T Integer](lhs, rhs T) T {
+	return lhs - rhs
+}
+
+func WrappingMul[T Integer](lhs, rhs T) T {
+	return lhs * rhs
+}
+
+// WrappingDiv returns Min for Min / -1 and panics on division by zero
+func WrappingDiv[T Integer](lhs, rhs T) T {
+	return lhs / rhs
+}
+
+func WrappingNeg[T Integer](value T) T {
+	return -value
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,41 +14,98 @@ import (
 
 // go test -v homework_test.go
 
+// Formatter builds the message of a MultiError from its errors
+type Formatter func(errs []error) string
+
+func BulletFormatter(errs []error) string {
+	var builder strings.Builder
+	builder.WriteString(strconv.Itoa(len(errs)) + " errors occured:\n")
+
+	for _, err := range errs {
+		builder.WriteString("\t* " + err.Error() + "\n")
+	}
+
+	return builder.String()
+}
+
+func SingleLineFormatter(errs []error) string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// JSONFormatter returns a JSON array of messages
+func JSONFormatter(errs []error) string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	data, _ := json.Marshal(messages)
+	return string(data)
+}
+
 type MultiError struct {
-	errors []error
+	errors    []error
+	formatter Formatter
 }
 
 func (e *MultiError) Error() string {
-	result := strconv.Itoa(len(e.errors)) + " errors occured:\n"
-
-	for _, err := range e.errors {
-		result += "\t* " + err.Error()
+	if e.formatter == nil {
+		return BulletFormatter(e.errors)
 	}
 
-	result += "\n"
+	return e.formatter(e.errors)
+}
 
-	return result
+func (e *MultiError) WithFormatter(formatter Formatter) *MultiError {
+	e.formatter = formatter
+	return e
 }
 
+// Append skips nil errors and flattens nested MultiErrors,
+// so every child is a leaf for errors.Is and errors.As
 func Append(err error, errs ...error) *MultiError {
-	switch e := any(err).(type) {
-	case *MultiError:
-		e.errors = append(e.errors, errs...)
+	result, ok := err.(*MultiError)
+	if !ok || result == nil {
+		result = &MultiError{}
+		result.append(err)
+	}
+
+	for _, err := range errs {
+		result.append(err)
+	}
 
-		return e
+	return result
+}
+
+func (e *MultiError) append(err error) {
+	switch err := err.(type) {
 	case nil:
-		return &MultiError{errs}
+	case *MultiError:
+		if err != nil {
+			e.errors = append(e.errors, err.errors...)
+		}
 	default:
-		return &MultiError{append([]error{err}, errs...)}
+		e.errors = append(e.errors, err)
 	}
 }
 
-func (e *MultiError) Unwrap() error {
-	if len(e.errors) == 1 {
+// Unwrap makes errors.Is and errors.As check every child as for errors.Join
+func (e *MultiError) Unwrap() []error {
+	return e.errors
+}
+
+// ErrorOrNil returns nil when no errors were appended
+func (e *MultiError) ErrorOrNil() error {
+	if e == nil || len(e.errors) == 0 {
 		return nil
 	}
 
-	return &MultiError{e.errors[0 : len(e.errors)-1]}
+	return e
 }
 
 func TestMultiError(t *testing.T) {
@@ -53,16 +113,71 @@ func TestMultiError(t *testing.T) {
 	err = Append(err, errors.New("error 1"))
 	err = Append(err, errors.New("error 2"))
 
-	expectedMessage := "2 errors occured:\n\t* error 1\t* error 2\n"
+	expectedMessage := "2 errors occured:\n\t* error 1\n\t* error 2\n"
 	assert.EqualError(t, err, expectedMessage)
+	assert.Nil(t, errors.Unwrap(err))
 
-	err2 := errors.Unwrap(err)
-	expectedMessage2 := "1 errors occured:\n\t* error 1\n"
-	assert.EqualError(t, err2, expectedMessage2)
-	err3 := errors.Unwrap(err2)
-	assert.Equal(t, err3, nil)
-
-	err4 := Append(nil, &os.SyscallError{"error", errors.New("error 4")})
+	err4 := Append(nil, &os.SyscallError{Syscall: "error", Err: errors.New("error 4")})
 	expectedMessage4 := "1 errors occured:\n\t* error: error 4\n"
 	assert.EqualError(t, err4, expectedMessage4)
 }
+
+func TestMultiErrorIsAs(t *testing.T) {
+	errFirst := errors.New("first")
+	errLast := errors.New("last")
+	syscallErr := &os.SyscallError{Syscall: "open", Err: os.ErrNotExist}
+
+	nested := Append(errFirst, fmt.Errorf("wrapped: %w", syscallErr))
+	err := Append(nested, nil, errLast, Append(nil, nil))
+
+	assert.Len(t, err.Unwrap(), 3)
+	assert.ErrorIs(t, err, errFirst)
+	assert.ErrorIs(t, err, errLast)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	var target *os.SyscallError
+	assert.ErrorAs(t, err, &target)
+	assert.Equal(t, "open", target.Syscall)
+
+	// a wrapped MultiError isn't flattened but is still unwrapped
+	wrapped := fmt.Errorf("request failed: %w", Append(nil, errLast))
+	assert.ErrorIs(t, Append(nil, wrapped), errLast)
+	assert.NotErrorIs(t, err, os.ErrPermission)
+}
+
+func TestMultiErrorFormatters(t *testing.T) {
+	err := Append(nil, errors.New("error 1"), errors.New(`error "2"`))
+
+	tests := map[string]struct {
+		formatter Formatter
+		message   string
+	}{
+		"default": {
+			message: "2 errors occured:\n\t* error 1\n\t* error \"2\"\n",
+		},
+		"single line": {
+			formatter: SingleLineFormatter,
+			message:   `error 1; error "2"`,
+		},
+		"json": {
+			formatter: JSONFormatter,
+			message:   `["error 1","error \"2\""]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, err.WithFormatter(test.formatter), test.message)
+		})
+	}
+}
+
+func TestMultiErrorNil(t *testing.T) {
+	err := Append(nil, nil)
+	assert.Empty(t, err.Unwrap())
+	assert.NoError(t, err.ErrorOrNil())
+
+	var multiErr *MultiError
+	assert.NoError(t, multiErr.ErrorOrNil())
+	assert.Error(t, Append(multiErr, errors.New("error")).ErrorOrNil())
+}
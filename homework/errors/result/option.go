@@ -0,0 +1,147 @@
+package result
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var ErrNoValue = errors.New("option has no value")
+
+var null = []byte("null")
+
+// Option is a value type, the zero Option is None
+type Option[T any] struct {
+	value   T
+	present bool
+}
+
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, present: true}
+}
+
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// OptionOf converts the comma ok idiom, like value, ok := cache[key]
+func OptionOf[T any](value T, ok bool) Option[T] {
+	if !ok {
+		return None[T]()
+	}
+
+	return Some(value)
+}
+
+// FromPointer returns None for nil
+func FromPointer[T any](pointer *T) Option[T] {
+	if pointer == nil {
+		return None[T]()
+	}
+
+	return Some(*pointer)
+}
+
+func (o Option[T]) IsSome() bool {
+	return o.present
+}
+
+func (o Option[T]) IsNone() bool {
+	return !o.present
+}
+
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+
+// Unwrap panics with ErrNoValue for None
+func (o Option[T]) Unwrap() T {
+	if !o.present {
+		panic(ErrNoValue)
+	}
+
+	return o.value
+}
+
+func (o Option[T]) UnwrapOr(value T) T {
+	if !o.present {
+		return value
+	}
+
+	return o.value
+}
+
+func (o Option[T]) UnwrapOrElse(action func() T) T {
+	if !o.present {
+		return action()
+	}
+
+	return o.value
+}
+
+func (o Option[T]) OrElse(action func() Option[T]) Option[T] {
+	if !o.present {
+		return action()
+	}
+
+	return o
+}
+
+// Filter returns None when the predicate is false
+func (o Option[T]) Filter(action func(T) bool) Option[T] {
+	if o.present && !action(o.value) {
+		return None[T]()
+	}
+
+	return o
+}
+
+// OkOr converts None to a Result with the error
+func (o Option[T]) OkOr(err error) Result[T] {
+	if !o.present {
+		return Err[T](err)
+	}
+
+	return Ok(o.value)
+}
+
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.present {
+		return null, nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), null) {
+		*o = None[T]()
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*o = Some(value)
+	return nil
+}
+
+// MapOption and AndThenOption are functions because methods can't have type parameters
+
+func MapOption[T, U any](o Option[T], action func(T) U) Option[U] {
+	if !o.present {
+		return None[U]()
+	}
+
+	return Some(action(o.value))
+}
+
+func AndThenOption[T, U any](o Option[T], action func(T) Option[U]) Option[U] {
+	if !o.present {
+		return None[U]()
+	}
+
+	return action(o.value)
+}
@@ -0,0 +1,80 @@
+package result
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func TestOption(t *testing.T) {
+	cache := map[string]int{"answer": 42}
+
+	answer := OptionOf(cache["answer"], true)
+	value, ok := answer.Get()
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+
+	missing, ok := cache["missing"]
+	empty := OptionOf(missing, ok)
+	assert.True(t, empty.IsNone())
+	assert.Equal(t, -1, empty.UnwrapOr(-1))
+	assert.Equal(t, 7, empty.UnwrapOrElse(func() int { return 7 }))
+	assert.Equal(t, 42, empty.OrElse(func() Option[int] { return answer }).Unwrap())
+	assert.PanicsWithValue(t, ErrNoValue, func() { empty.Unwrap() })
+
+	var zero Option[string]
+	assert.True(t, zero.IsNone())
+
+	number := 5
+	assert.Equal(t, Some(5), FromPointer(&number))
+	assert.Equal(t, None[int](), FromPointer[int](nil))
+}
+
+func TestOptionCombinators(t *testing.T) {
+	text := MapOption(Some(42), strconv.Itoa)
+	assert.Equal(t, Some("42"), text)
+	assert.Equal(t, None[string](), MapOption(None[int](), strconv.Itoa))
+
+	parse := func(text string) Option[int] {
+		number, err := strconv.Atoi(text)
+		return OptionOf(number, err == nil)
+	}
+
+	assert.Equal(t, Some(42), AndThenOption(text, parse))
+	assert.Equal(t, None[int](), AndThenOption(Some("x"), parse))
+
+	even := func(number int) bool { return number%2 == 0 }
+	assert.Equal(t, Some(2), Some(2).Filter(even))
+	assert.True(t, Some(3).Filter(even).IsNone())
+
+	assert.Equal(t, Ok(1), Some(1).OkOr(ErrNoValue))
+	assert.ErrorIs(t, None[int]().OkOr(ErrNoValue).Err(), ErrNoValue)
+}
+
+func TestOptionJSON(t *testing.T) {
+	type User struct {
+		Name     string         `json:"name"`
+		Nickname Option[string] `json:"nickname"`
+		Age      Option[int]    `json:"age"`
+	}
+
+	data, err := json.Marshal(User{Name: "Ivan", Age: Some(0)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Ivan","nickname":null,"age":0}`, string(data))
+
+	var user User
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Ivan","nickname":"vanya","age":null}`), &user))
+	assert.Equal(t, User{Name: "Ivan", Nickname: Some("vanya")}, user)
+
+	// a missing field is None as well
+	user = User{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Petr"}`), &user))
+	assert.True(t, user.Nickname.IsNone())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"age":"old"}`), &user))
+}
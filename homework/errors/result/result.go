@@ -0,0 +1,175 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Result holds either a value or an error
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err with a nil error is the same as Ok with the zero value
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// Of converts the (T, error) idiom, like Of(strconv.Atoi(text))
+func Of[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+
+	return Ok(value)
+}
+
+// Get converts back to the (T, error) idiom
+func (r Result[T]) Get() (T, error) {
+	if r.err != nil {
+		var zero T
+		return zero, r.err
+	}
+
+	return r.value, nil
+}
+
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// Unwrap panics with the error
+func (r Result[T]) Unwrap() T {
+	if r.err != nil {
+		panic(fmt.Errorf("unwrap result: %w", r.err))
+	}
+
+	return r.value
+}
+
+func (r Result[T]) UnwrapOr(value T) T {
+	if r.err != nil {
+		return value
+	}
+
+	return r.value
+}
+
+func (r Result[T]) UnwrapOrElse(action func(error) T) T {
+	if r.err != nil {
+		return action(r.err)
+	}
+
+	return r.value
+}
+
+// OrElse can recover from an error, like a fallback to a cache
+func (r Result[T]) OrElse(action func(error) Result[T]) Result[T] {
+	if r.err != nil {
+		return action(r.err)
+	}
+
+	return r
+}
+
+// Option drops the error
+func (r Result[T]) Option() Option[T] {
+	return OptionOf(r.value, r.err == nil)
+}
+
+type resultJSON[T any] struct {
+	Value *T      `json:"value,omitempty"`
+	Error *string `json:"error,omitempty"`
+}
+
+// MarshalJSON writes {"value": ...} or {"error": "message"},
+// errors are restored as plain errors with the same message
+func (r Result[T]) MarshalJSON() ([]byte, error) {
+	if r.err != nil {
+		message := r.err.Error()
+		return json.Marshal(resultJSON[T]{Error: &message})
+	}
+
+	return json.Marshal(resultJSON[T]{Value: &r.value})
+}
+
+func (r *Result[T]) UnmarshalJSON(data []byte) error {
+	var decoded resultJSON[T]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	switch {
+	case decoded.Error != nil:
+		*r = Err[T](errors.New(*decoded.Error))
+	case decoded.Value != nil:
+		*r = Ok(*decoded.Value)
+	default:
+		// a null value is omitted by the encoder
+		*r = Ok(*new(T))
+	}
+
+	return nil
+}
+
+func Map[T, U any](r Result[T], action func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+
+	return Ok(action(r.value))
+}
+
+// MapErr changes the error, like wrapping it with a context
+func MapErr[T any](r Result[T], action func(error) error) Result[T] {
+	if r.err != nil {
+		return Err[T](action(r.err))
+	}
+
+	return r
+}
+
+func AndThen[T, U any](r Result[T], action func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+
+	return action(r.value)
+}
+
+// Try is AndThen for functions in the (T, error) idiom
+func Try[T, U any](r Result[T], action func(T) (U, error)) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+
+	return Of(action(r.value))
+}
+
+// Collect returns all values or the first error
+func Collect[T any](results []Result[T]) Result[[]T] {
+	values := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			return Err[[]T](r.err)
+		}
+
+		values = append(values, r.value)
+	}
+
+	return Ok(values)
+}
@@ -0,0 +1,130 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNegative = errors.New("negative number")
+
+func positive(number int) Result[int] {
+	if number < 0 {
+		return Err[int](errNegative)
+	}
+
+	return Ok(number)
+}
+
+func TestResult(t *testing.T) {
+	parsed := Of(strconv.Atoi("42"))
+	assert.True(t, parsed.IsOk())
+	assert.Equal(t, 42, parsed.Unwrap())
+
+	value, err := parsed.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	failed := Of(strconv.Atoi("x"))
+	assert.True(t, failed.IsErr())
+	assert.ErrorIs(t, failed.Err(), strconv.ErrSyntax)
+	assert.Equal(t, -1, failed.UnwrapOr(-1))
+	assert.Equal(t, 0, failed.UnwrapOrElse(func(error) int { return 0 }))
+	assert.Panics(t, func() { failed.Unwrap() })
+
+	value, err = failed.Get()
+	assert.Error(t, err)
+	assert.Zero(t, value)
+
+	recovered := failed.OrElse(func(err error) Result[int] {
+		return Ok(100)
+	})
+	assert.Equal(t, Ok(100), recovered)
+	assert.Equal(t, parsed, parsed.OrElse(func(error) Result[int] { return Ok(0) }))
+
+	assert.Equal(t, Some(42), parsed.Option())
+	assert.Equal(t, None[int](), failed.Option())
+	assert.True(t, Err[int](nil).IsOk())
+}
+
+func TestResultCombinators(t *testing.T) {
+	// parse, validate, double and format without an if err != nil after every step
+	handle := func(text string) Result[string] {
+		number := Of(strconv.Atoi(text))
+		checked := AndThen(number, positive)
+		doubled := Map(checked, func(number int) int { return number * 2 })
+		return MapErr(Map(doubled, strconv.Itoa), func(err error) error {
+			return fmt.Errorf("handle %q: %w", text, err)
+		})
+	}
+
+	assert.Equal(t, Ok("42"), handle("21"))
+	assert.ErrorIs(t, handle("-1").Err(), errNegative)
+	assert.EqualError(t, handle("-1").Err(), `handle "-1": negative number`)
+	assert.ErrorIs(t, handle("x").Err(), strconv.ErrSyntax)
+
+	quoted := Try(Ok("\"text\""), strconv.Unquote)
+	assert.Equal(t, Ok("text"), quoted)
+	assert.ErrorIs(t, Try(Err[string](errNegative), strconv.Unquote).Err(), errNegative)
+}
+
+func TestCollect(t *testing.T) {
+	tests := map[string]struct {
+		results []Result[int]
+		values  []int
+		err     error
+	}{
+		"empty": {
+			values: []int{},
+		},
+		"all ok": {
+			results: []Result[int]{Ok(1), Ok(2), Ok(3)},
+			values:  []int{1, 2, 3},
+		},
+		"first error": {
+			results: []Result[int]{Ok(1), Err[int](errNegative), Err[int](strconv.ErrRange)},
+			err:     errNegative,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := Collect(test.results).Get()
+			assert.Equal(t, test.values, values)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	tests := map[string]struct {
+		result Result[[]int]
+		json   string
+	}{
+		"value":      {result: Ok([]int{1, 2}), json: `{"value":[1,2]}`},
+		"null value": {result: Ok[[]int](nil), json: `{"value":null}`},
+		"error":      {result: Err[[]int](errNegative), json: `{"error":"negative number"}`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(test.result)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.json, string(data))
+
+			var decoded Result[[]int]
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, test.result.IsOk(), decoded.IsOk())
+			if test.result.IsOk() {
+				assert.Equal(t, test.result, decoded)
+			} else {
+				assert.EqualError(t, decoded.Err(), test.result.Err().Error())
+			}
+		})
+	}
+}
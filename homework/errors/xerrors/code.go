@@ -0,0 +1,43 @@
+package xerrors
+
+import "strconv"
+
+// Code classifies errors like gRPC status codes, codes are errors
+// themselves, so errors.Is(err, NotFound) checks the code of err
+type Code uint8
+
+const (
+	Unknown Code = iota
+	InvalidArgument
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	Unauthenticated
+	DeadlineExceeded
+	Unavailable
+	Internal
+)
+
+var codeNames = [...]string{
+	Unknown:          "Unknown",
+	InvalidArgument:  "InvalidArgument",
+	NotFound:         "NotFound",
+	AlreadyExists:    "AlreadyExists",
+	PermissionDenied: "PermissionDenied",
+	Unauthenticated:  "Unauthenticated",
+	DeadlineExceeded: "DeadlineExceeded",
+	Unavailable:      "Unavailable",
+	Internal:         "Internal",
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+
+	return "Code(" + strconv.Itoa(int(c)) + ")"
+}
+
+func (c Code) Error() string {
+	return c.String()
+}
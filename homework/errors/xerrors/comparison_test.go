@@ -0,0 +1,39 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// go test -bench=. -benchmem
+
+var err error
+
+var errBase = errors.New("error")
+
+func BenchmarkFmtErrorf(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		err = fmt.Errorf("message: %w", errBase)
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		err = Wrap(errBase, "message")
+	}
+}
+
+// only the first wrap captures a stack
+func BenchmarkWrapTwice(b *testing.B) {
+	wrapped := Wrap(errBase, "message")
+	for i := 0; i < b.N; i++ {
+		err = Wrap(wrapped, "message")
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		err = WithFields(errBase, "user", 42, "request", "abc")
+	}
+}
@@ -0,0 +1,56 @@
+package xerrors
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// Format prints the message for %s, %v and other verbs, %+v adds the code, fields and the stack
+func (e *Error) Format(state fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if state.Flag('+') {
+			e.writeVerbose(state)
+			return
+		}
+
+		_, _ = io.WriteString(state, e.Error())
+	case 's':
+		_, _ = io.WriteString(state, e.Error())
+	case 'q':
+		_, _ = io.WriteString(state, strconv.Quote(e.Error()))
+	default:
+		_, _ = io.WriteString(state, e.Error())
+	}
+}
+
+func (e *Error) writeVerbose(writer io.Writer) {
+	_, _ = io.WriteString(writer, e.Error())
+	_, _ = fmt.Fprintf(writer, "\ncode: %s", CodeOf(e))
+
+	for _, field := range Fields(e) {
+		_, _ = fmt.Fprintf(writer, "\n%s: %v", field.Key, field.Value)
+	}
+
+	for _, frame := range StackTrace(e) {
+		_, _ = fmt.Fprintf(writer, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+	}
+}
+
+// LogValue groups the message, the code, fields and the place
+// where the error happened, so slog prints them as separate keys
+func (e *Error) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("message", e.Error()),
+		slog.String("code", CodeOf(e).String()),
+	}
+
+	attrs = append(attrs, Fields(e)...)
+	if frames := StackTrace(e); len(frames) > 0 {
+		attrs = append(attrs, slog.String("source", frames[0].File+":"+strconv.Itoa(frames[0].Line)))
+	}
+
+	return slog.GroupValue(attrs...)
+}
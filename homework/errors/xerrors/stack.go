@@ -0,0 +1,28 @@
+package xerrors
+
+import (
+	"runtime"
+)
+
+const maxStackDepth = 32
+
+// stack keeps program counters only, frames are resolved when printed
+type stack []uintptr
+
+func callers(skip int) stack {
+	var pcs [maxStackDepth]uintptr
+	count := runtime.Callers(skip+2, pcs[:])
+	return stack(pcs[:count:count])
+}
+
+func (s stack) frames() []runtime.Frame {
+	result := make([]runtime.Frame, 0, len(s))
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		result = append(result, frame)
+		if !more {
+			return result
+		}
+	}
+}
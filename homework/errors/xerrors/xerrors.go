@@ -0,0 +1,184 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+const badKey = "!BADKEY"
+
+// Error adds a message, a code and fields to its cause,
+// the stack is captured only by the innermost Error of a chain
+type Error struct {
+	message string
+	code    Code
+	fields  []slog.Attr
+	cause   error
+	stack   stack
+}
+
+func New(code Code, message string) error {
+	return &Error{message: message, code: code, stack: callers(1)}
+}
+
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{message: fmt.Sprintf(format, args...), code: code, stack: callers(1)}
+}
+
+// Wrap returns nil for a nil error
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, message)
+}
+
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, fmt.Sprintf(format, args...))
+}
+
+// WithCode sets the code without changing the message
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+
+	wrapped := wrap(err, "")
+	wrapped.code = code
+	return wrapped
+}
+
+// WithFields adds key/value pairs in the log/slog style: keys are strings
+// followed by values, slog.Attr values are used as is
+func WithFields(err error, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	wrapped := wrap(err, "")
+	wrapped.fields = attrs(args)
+	return wrapped
+}
+
+func wrap(err error, message string) *Error {
+	wrapped := &Error{message: message, cause: err}
+	if stackOf(err) == nil {
+		wrapped.stack = callers(2)
+	}
+
+	return wrapped
+}
+
+func attrs(args []any) []slog.Attr {
+	var result []slog.Attr
+	for len(args) > 0 {
+		switch key := args[0].(type) {
+		case slog.Attr:
+			result = append(result, key)
+			args = args[1:]
+		case string:
+			if len(args) == 1 {
+				result = append(result, slog.String(badKey, key))
+				return result
+			}
+
+			result = append(result, slog.Any(key, args[1]))
+			args = args[2:]
+		default:
+			result = append(result, slog.Any(badKey, key))
+			args = args[1:]
+		}
+	}
+
+	return result
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.cause == nil:
+		return e.message
+	case e.message == "":
+		return e.cause.Error()
+	default:
+		return e.message + ": " + e.cause.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches the code set on this error, errors.Is walks the chain, so errors.Is(err, NotFound)
+// works for wrapped errors, Unknown isn't a code, it never matches errors without one
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && e.code != Unknown && code == e.code
+}
+
+// CodeOf returns the outermost code in the chain, Unknown when there is no code
+func CodeOf(err error) Code {
+	for err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			return Unknown
+		}
+
+		if e.code != Unknown {
+			return e.code
+		}
+
+		err = e.cause
+	}
+
+	return Unknown
+}
+
+// Fields returns fields of all errors in the chain, outer fields go first
+func Fields(err error) []slog.Attr {
+	var result []slog.Attr
+	for err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			break
+		}
+
+		result = append(result, e.fields...)
+		err = e.cause
+	}
+
+	return result
+}
+
+// StackTrace returns frames captured by the innermost Error of the chain
+func StackTrace(err error) []runtime.Frame {
+	if s := stackOf(err); s != nil {
+		return s.frames()
+	}
+
+	return nil
+}
+
+func stackOf(err error) stack {
+	for err != nil {
+		// the type assertion skips errors.As for chains of Error
+		e, ok := err.(*Error)
+		if !ok && !errors.As(err, &e) {
+			return nil
+		}
+
+		if e.stack != nil {
+			return e.stack
+		}
+
+		err = e.cause
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func findUser(id int) error {
+	return WithFields(New(NotFound, "user not found"), "user", id)
+}
+
+func TestWrap(t *testing.T) {
+	err := Wrapf(findUser(42), "load profile %d", 42)
+	err = Wrap(WithFields(err, "request", "abc"), "handle request")
+
+	assert.EqualError(t, err, "handle request: load profile 42: user not found")
+	assert.Equal(t, NotFound, CodeOf(err))
+	assert.Equal(t, []slog.Attr{slog.String("request", "abc"), slog.Int("user", 42)}, Fields(err))
+
+	// the stack is captured once where the error was created
+	frames := StackTrace(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, "xerrors.findUser"), frames[0].Function)
+
+	assert.NoError(t, Wrap(nil, "message"))
+	assert.NoError(t, Wrapf(nil, "message %d", 1))
+	assert.NoError(t, WithCode(nil, Internal))
+	assert.NoError(t, WithFields(nil, "key", "value"))
+}
+
+func TestWrapForeignError(t *testing.T) {
+	_, openErr := os.Open("/not/existing/file")
+	err := WithCode(Wrap(openErr, "read config"), Internal)
+
+	assert.Equal(t, Internal, CodeOf(err))
+	assert.Equal(t, Unknown, CodeOf(openErr))
+	assert.Equal(t, Unknown, CodeOf(nil))
+
+	frames := StackTrace(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, "TestWrapForeignError"), frames[0].Function)
+	assert.Nil(t, StackTrace(openErr))
+}
+
+func TestIsAs(t *testing.T) {
+	err := fmt.Errorf("outer: %w", Wrap(findUser(1), "inner"))
+
+	assert.ErrorIs(t, err, NotFound)
+	assert.NotErrorIs(t, err, Internal)
+
+	var target *Error
+	require.ErrorAs(t, err, &target)
+	assert.Equal(t, NotFound, CodeOf(target))
+
+	_, openErr := os.Open("/not/existing/file")
+	wrapped := WithCode(Wrapf(openErr, "open %s", "config"), Unavailable)
+	assert.ErrorIs(t, wrapped, fs.ErrNotExist)
+	assert.ErrorIs(t, wrapped, Unavailable)
+
+	var pathErr *fs.PathError
+	require.ErrorAs(t, wrapped, &pathErr)
+	assert.Equal(t, "/not/existing/file", pathErr.Path)
+
+	assert.ErrorIs(t, Wrap(io.EOF, "read"), io.EOF)
+	assert.NotErrorIs(t, Wrap(io.EOF, "read"), Unknown)
+
+	// the outer code is the code of the chain
+	overridden := WithCode(findUser(2), Internal)
+	assert.Equal(t, Internal, CodeOf(overridden))
+	assert.ErrorIs(t, overridden, Internal)
+	assert.NotErrorIs(t, WithFields(findUser(3), "attempt", 1), Internal)
+}
+
+func TestFieldArguments(t *testing.T) {
+	err := WithFields(io.EOF, "string", "value", slog.Bool("attr", true), 42, "odd")
+	assert.Equal(t, []slog.Attr{
+		slog.String("string", "value"),
+		slog.Bool("attr", true),
+		slog.Int(badKey, 42),
+		slog.String(badKey, "odd"),
+	}, Fields(err))
+}
+
+func TestFormat(t *testing.T) {
+	err := Wrap(findUser(7), "load")
+
+	assert.Equal(t, "load: user not found", fmt.Sprintf("%v", err))
+	assert.Equal(t, "load: user not found", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"load: user not found"`, fmt.Sprintf("%q", err))
+	assert.Equal(t, "load: user not found", fmt.Sprintf("%d", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	lines := strings.Split(verbose, "\n")
+	require.Greater(t, len(lines), 4)
+	assert.Equal(t, []string{"load: user not found", "code: NotFound", "user: 7"}, lines[:3])
+	assert.True(t, strings.HasSuffix(lines[3], "xerrors.findUser"), lines[3])
+	assert.Contains(t, lines[4], "xerrors_test.go:")
+
+	assert.Equal(t, "Code(200)", Code(200).String())
+}
+
+func TestLogValue(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, nil))
+	logger.Error("request failed", "error", Errorf(PermissionDenied, "no access to %s", "orders"))
+
+	var record struct {
+		Error map[string]string `json:"error"`
+	}
+
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "no access to orders", record.Error["message"])
+	assert.Equal(t, "PermissionDenied", record.Error["code"])
+	assert.Contains(t, record.Error["source"], "xerrors_test.go:")
+}
+
+func TestStdlibCompatibility(t *testing.T) {
+	errFirst := errors.New("first")
+	joined := errors.Join(Wrap(errFirst, "wrapped"), New(AlreadyExists, "duplicate"))
+
+	assert.ErrorIs(t, joined, errFirst)
+	assert.ErrorIs(t, joined, AlreadyExists)
+	assert.Equal(t, errFirst, errors.Unwrap(Wrap(errFirst, "wrapped")))
+}
@@ -50,7 +50,7 @@ func Filter[T Number](data []T, action func(T) bool) []T {
 }
 
 func Reduce[T Number](data []T, initial T, action func(T, T) T) T {
-	result := action(0, initial)
+	result := initial
 
 	for _, v := range data {
 		result = action(v, result)
@@ -176,6 +176,14 @@ func TestReduce(t *testing.T) {
 			},
 			result: 25,
 		},
+		"product of numbers": {
+			initial: 1,
+			data:    []int{1, 2, 3, 4, 5},
+			action: func(lhs, rhs int) int {
+				return lhs * rhs
+			},
+			result: 120,
+		},
 	}
 
 	for name, test := range tests {
@@ -0,0 +1,62 @@
+package parallel
+
+import (
+	"context"
+	"testing"
+)
+
+// go test -bench=. -benchmem
+
+var Sink []int
+
+func BenchmarkMap(b *testing.B) {
+	data := numbers(10_000_000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result := make([]int, len(data))
+		for index, number := range data {
+			result[index] = number * number
+		}
+
+		Sink = result
+	}
+}
+
+func BenchmarkParallelMap(b *testing.B) {
+	data := numbers(10_000_000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Sink, _ = ParallelMap(context.Background(), data, func(number int) (int, error) {
+			return number * number, nil
+		})
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	data := numbers(10_000_000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var result []int
+		for _, number := range data {
+			if number%2 == 0 {
+				result = append(result, number)
+			}
+		}
+
+		Sink = result
+	}
+}
+
+func BenchmarkParallelFilter(b *testing.B) {
+	data := numbers(10_000_000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Sink, _ = ParallelFilter(context.Background(), data, func(number int) (bool, error) {
+			return number%2 == 0, nil
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// chunks per worker, more chunks balance uneven work better
+const chunksPerWorker = 8
+
+// elements processed between context checks inside a chunk,
+// so cancellation doesn't wait for whole chunks of large inputs
+const contextCheckInterval = 1024
+
+type ParallelOption func(*parallelConfig)
+
+type parallelConfig struct {
+	workers   int
+	chunkSize int
+}
+
+// WithWorkers limits the number of goroutines, GOMAXPROCS by default
+func WithWorkers(workers int) ParallelOption {
+	return func(config *parallelConfig) {
+		if workers > 0 {
+			config.workers = workers
+		}
+	}
+}
+
+func WithChunkSize(chunkSize int) ParallelOption {
+	return func(config *parallelConfig) {
+		if chunkSize > 0 {
+			config.chunkSize = chunkSize
+		}
+	}
+}
+
+func newParallelConfig(length int, options []ParallelOption) parallelConfig {
+	config := parallelConfig{workers: runtime.GOMAXPROCS(0)}
+	for _, option := range options {
+		option(&config)
+	}
+
+	if config.chunkSize == 0 {
+		config.chunkSize = max(1, (length+config.workers*chunksPerWorker-1)/(config.workers*chunksPerWorker))
+	}
+
+	return config
+}
+
+func (c parallelConfig) chunks(length int) int {
+	return (length + c.chunkSize - 1) / c.chunkSize
+}
+
+// checkContext returns the context error every contextCheckInterval elements of a chunk
+func checkContext(ctx context.Context, processed int) error {
+	if processed == 0 || processed%contextCheckInterval != 0 {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// forEachChunk calls action for chunks from a shared counter, so fast workers take more
+// chunks, the context is checked between chunks and the first error stops all workers,
+// action gets a context that is cancelled when any of the workers fails
+func forEachChunk(ctx context.Context, length int, config parallelConfig, action func(ctx context.Context, chunk, start, end int) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := config.chunks(length)
+	var next atomic.Int64
+	var once sync.Once
+	var firstErr error
+
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	for range min(config.workers, chunks) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= chunks {
+					return
+				}
+
+				if err := ctx.Err(); err != nil {
+					fail(err)
+					return
+				}
+
+				start := chunk * config.chunkSize
+				end := min(start+config.chunkSize, length)
+				if err := action(ctx, chunk, start, end); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// ParallelMap keeps the order of data in the result
+func ParallelMap[T, U any](ctx context.Context, data []T, action func(T) (U, error), options ...ParallelOption) ([]U, error) {
+	if data == nil {
+		return nil, ctx.Err()
+	}
+
+	config := newParallelConfig(len(data), options)
+	result := make([]U, len(data))
+
+	err := forEachChunk(ctx, len(data), config, func(ctx context.Context, _, start, end int) error {
+		for index := start; index < end; index++ {
+			if err := checkContext(ctx, index-start); err != nil {
+				return err
+			}
+
+			value, err := action(data[index])
+			if err != nil {
+				return err
+			}
+
+			result[index] = value
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParallelFilter filters chunks independently and joins them in the order of data
+func ParallelFilter[T any](ctx context.Context, data []T, action func(T) (bool, error), options ...ParallelOption) ([]T, error) {
+	if data == nil {
+		return nil, ctx.Err()
+	}
+
+	config := newParallelConfig(len(data), options)
+	filtered := make([][]T, config.chunks(len(data)))
+
+	err := forEachChunk(ctx, len(data), config, func(ctx context.Context, chunk, start, end int) error {
+		for processed, value := range data[start:end] {
+			if err := checkContext(ctx, processed); err != nil {
+				return err
+			}
+
+			keep, err := action(value)
+			if err != nil {
+				return err
+			}
+
+			if keep {
+				filtered[chunk] = append(filtered[chunk], value)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	length := 0
+	for _, values := range filtered {
+		length += len(values)
+	}
+
+	result := make([]T, 0, length)
+	for _, values := range filtered {
+		result = append(result, values...)
+	}
+
+	return result, nil
+}
+
+// ParallelReduce needs an associative action: every chunk is reduced starting from
+// its first element and partial results are combined in order after initial,
+// so action doesn't have to be commutative and initial doesn't have to be neutral
+func ParallelReduce[T any](ctx context.Context, data []T, initial T, action func(T, T) T, options ...ParallelOption) (T, error) {
+	if len(data) == 0 {
+		return initial, ctx.Err()
+	}
+
+	config := newParallelConfig(len(data), options)
+	partials := make([]T, config.chunks(len(data)))
+
+	err := forEachChunk(ctx, len(data), config, func(ctx context.Context, chunk, start, end int) error {
+		partial := data[start]
+		for processed, value := range data[start+1 : end] {
+			if err := checkContext(ctx, processed+1); err != nil {
+				return err
+			}
+
+			partial = action(partial, value)
+		}
+
+		partials[chunk] = partial
+		return nil
+	})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	result := initial
+	for _, partial := range partials {
+		result = action(result, partial)
+	}
+
+	return result, nil
+}
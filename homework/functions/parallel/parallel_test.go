@@ -0,0 +1,182 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numbers(count int) []int {
+	data := make([]int, count)
+	for index := range data {
+		data[index] = index
+	}
+
+	return data
+}
+
+func TestParallelMap(t *testing.T) {
+	tests := map[string]struct {
+		data    []int
+		options []ParallelOption
+	}{
+		"nil numbers":    {},
+		"empty numbers":  {data: []int{}},
+		"single worker":  {data: numbers(100), options: []ParallelOption{WithWorkers(1)}},
+		"small chunks":   {data: numbers(1001), options: []ParallelOption{WithWorkers(4), WithChunkSize(7)}},
+		"default config": {data: numbers(100_000)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := ParallelMap(context.Background(), test.data, func(number int) (string, error) {
+				return strconv.Itoa(number), nil
+			}, test.options...)
+			require.NoError(t, err)
+
+			if test.data == nil {
+				assert.Nil(t, result)
+				return
+			}
+
+			require.Len(t, result, len(test.data))
+			for index, text := range result {
+				if text != strconv.Itoa(index) {
+					t.Fatalf("result[%d] = %s", index, text)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelFilter(t *testing.T) {
+	result, err := ParallelFilter(context.Background(), numbers(10_000), func(number int) (bool, error) {
+		return number%3 == 0, nil
+	}, WithWorkers(8), WithChunkSize(10))
+	require.NoError(t, err)
+
+	var expected []int
+	for number := range 10_000 {
+		if number%3 == 0 {
+			expected = append(expected, number)
+		}
+	}
+
+	assert.Equal(t, expected, result)
+
+	result, err = ParallelFilter(context.Background(), []int{}, func(number int) (bool, error) {
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestParallelReduce(t *testing.T) {
+	sum, err := ParallelReduce(context.Background(), numbers(10_001), 5, func(lhs, rhs int) int {
+		return lhs + rhs
+	}, WithChunkSize(100))
+	require.NoError(t, err)
+	assert.Equal(t, 5+10_000*10_001/2, sum)
+
+	// concatenation is associative but not commutative
+	texts, err := ParallelMap(context.Background(), numbers(1000), func(number int) (string, error) {
+		return strconv.Itoa(number % 10), nil
+	})
+	require.NoError(t, err)
+
+	joined, err := ParallelReduce(context.Background(), texts, ">", func(lhs, rhs string) string {
+		return lhs + rhs
+	}, WithWorkers(4), WithChunkSize(3))
+	require.NoError(t, err)
+	assert.Len(t, joined, 1001)
+	assert.Equal(t, ">0123456789012", joined[:14])
+
+	empty, err := ParallelReduce(context.Background(), nil, 7, func(lhs, rhs int) int {
+		return lhs * rhs
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, empty)
+}
+
+func TestParallelErrors(t *testing.T) {
+	errOdd := errors.New("odd number")
+
+	var calls atomic.Int64
+	result, err := ParallelMap(context.Background(), numbers(100_000), func(number int) (int, error) {
+		calls.Add(1)
+		if number == 501 {
+			return 0, errOdd
+		}
+
+		return number, nil
+	}, WithWorkers(4), WithChunkSize(100))
+	assert.ErrorIs(t, err, errOdd)
+	assert.Nil(t, result)
+
+	// workers stop taking chunks after the first error
+	assert.Less(t, calls.Load(), int64(100_000))
+
+	_, err = ParallelFilter(context.Background(), numbers(10), func(number int) (bool, error) {
+		return false, errOdd
+	})
+	assert.ErrorIs(t, err, errOdd)
+}
+
+func TestParallelCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParallelMap(ctx, numbers(10), func(number int) (int, error) {
+		return number, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = ParallelReduce(ctx, numbers(10), 0, func(lhs, rhs int) int {
+		return lhs + rhs
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = ParallelFilter(ctx, numbers(1000), func(number int) (bool, error) {
+		time.Sleep(time.Millisecond)
+		return true, nil
+	}, WithWorkers(2), WithChunkSize(1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestParallelCancellationInsideChunk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a single chunk of the whole input, so cancellation is noticed only inside it
+	var calls atomic.Int64
+	_, err := ParallelMap(ctx, numbers(1_000_000), func(number int) (int, error) {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+
+		return number, nil
+	}, WithWorkers(1), WithChunkSize(1_000_000))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, calls.Load(), int64(contextCheckInterval))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	calls.Store(0)
+	_, err = ParallelReduce(ctx, numbers(1_000_000), 0, func(lhs, rhs int) int {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+
+		return lhs + rhs
+	}, WithWorkers(1), WithChunkSize(1_000_000))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, calls.Load(), int64(contextCheckInterval))
+}
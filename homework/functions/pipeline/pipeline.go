@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"iter"
+	"slices"
+)
+
+// all stages are lazy: nothing is read from a sequence until the result
+// is ranged over, and a stage stops its source as soon as a consumer stops
+
+func Map[T, U any](seq iter.Seq[T], action func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for value := range seq {
+			if !yield(action(value)) {
+				return
+			}
+		}
+	}
+}
+
+func Filter[T any](seq iter.Seq[T], action func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value := range seq {
+			if action(value) && !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+func FlatMap[T, U any](seq iter.Seq[T], action func(T) iter.Seq[U]) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for value := range seq {
+			for mapped := range action(value) {
+				if !yield(mapped) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Reduce is the only eager stage, it consumes the whole sequence
+func Reduce[T, A any](seq iter.Seq[T], initial A, action func(A, T) A) A {
+	result := initial
+	for value := range seq {
+		result = action(result, value)
+	}
+
+	return result
+}
+
+func Take[T any](seq iter.Seq[T], count int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if count <= 0 {
+			return
+		}
+
+		taken := 0
+		for value := range seq {
+			if !yield(value) {
+				return
+			}
+
+			// stop before the source produces an element that isn't needed
+			if taken++; taken == count {
+				return
+			}
+		}
+	}
+}
+
+func Skip[T any](seq iter.Seq[T], count int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		skipped := 0
+		for value := range seq {
+			if skipped < count {
+				skipped++
+				continue
+			}
+
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Chunk yields slices of size elements, the last one may be shorter,
+// every chunk is a new slice and can be retained by a consumer
+func Chunk[T any](seq iter.Seq[T], size int) iter.Seq[[]T] {
+	if size <= 0 {
+		panic("pipeline: chunk size must be positive")
+	}
+
+	return func(yield func([]T) bool) {
+		chunk := make([]T, 0, size)
+		for value := range seq {
+			chunk = append(chunk, value)
+			if len(chunk) < size {
+				continue
+			}
+
+			if !yield(chunk) {
+				return
+			}
+
+			chunk = make([]T, 0, size)
+		}
+
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// Window yields every run of size consecutive elements,
+// nothing is yielded when the sequence is shorter than size
+func Window[T any](seq iter.Seq[T], size int) iter.Seq[[]T] {
+	if size <= 0 {
+		panic("pipeline: window size must be positive")
+	}
+
+	return func(yield func([]T) bool) {
+		window := make([]T, 0, size)
+		for value := range seq {
+			if len(window) == size {
+				copy(window, window[1:])
+				window = window[:size-1]
+			}
+
+			window = append(window, value)
+			if len(window) == size && !yield(slices.Clone(window)) {
+				return
+			}
+		}
+	}
+}
+
+// Zip pairs elements of both sequences and stops with the shorter one
+func Zip[T, U any](lhs iter.Seq[T], rhs iter.Seq[U]) iter.Seq2[T, U] {
+	return func(yield func(T, U) bool) {
+		next, stop := iter.Pull(rhs)
+		defer stop()
+
+		for left := range lhs {
+			right, ok := next()
+			if !ok || !yield(left, right) {
+				return
+			}
+		}
+	}
+}
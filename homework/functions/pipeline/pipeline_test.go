@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v ./...
+
+// naturals is infinite, so every test using it checks short-circuiting
+func naturals(pulled *int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for number := 1; ; number++ {
+			*pulled = number
+			if !yield(number) {
+				return
+			}
+		}
+	}
+}
+
+func TestMapFilterReduce(t *testing.T) {
+	var pulled int
+	evens := Filter(naturals(&pulled), func(number int) bool {
+		return number%2 == 0
+	})
+
+	texts := Map(Take(evens, 3), strconv.Itoa)
+	assert.Zero(t, pulled)
+
+	assert.Equal(t, []string{"2", "4", "6"}, slices.Collect(texts))
+	assert.Equal(t, 6, pulled)
+
+	joined := Reduce(texts, "", func(result string, text string) string {
+		return result + text
+	})
+	assert.Equal(t, "246", joined)
+
+	sum := Reduce(slices.Values([]int{1, 2, 3}), 10, func(result int, number int) int {
+		return result + number
+	})
+	assert.Equal(t, 16, sum)
+	assert.Equal(t, 1, Reduce(slices.Values([]int(nil)), 1, func(result int, number int) int {
+		return result * number
+	}))
+}
+
+func TestTakeSkip(t *testing.T) {
+	tests := map[string]struct {
+		skip   int
+		take   int
+		result []int
+	}{
+		"take nothing":      {take: 0, result: nil},
+		"negative take":     {take: -1, result: nil},
+		"take first":        {take: 2, result: []int{1, 2}},
+		"skip and take":     {skip: 3, take: 2, result: []int{4, 5}},
+		"negative skip":     {skip: -1, take: 1, result: []int{1}},
+		"take more than is": {skip: 4, take: 10, result: []int{5}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := slices.Values([]int{1, 2, 3, 4, 5})
+			result := slices.Collect(Take(Skip(data, test.skip), test.take))
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestChunk(t *testing.T) {
+	chunks := slices.Collect(Chunk(slices.Values([]int{1, 2, 3, 4, 5}), 2))
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunks)
+
+	var pulled int
+	chunks = slices.Collect(Take(Chunk(naturals(&pulled), 3), 2))
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, chunks)
+	assert.Equal(t, 6, pulled)
+
+	assert.Empty(t, slices.Collect(Chunk(slices.Values([]int{}), 2)))
+	assert.Panics(t, func() { Chunk(slices.Values([]int{}), 0) })
+}
+
+func TestWindow(t *testing.T) {
+	windows := slices.Collect(Window(slices.Values([]int{1, 2, 3, 4}), 3))
+	assert.Equal(t, [][]int{{1, 2, 3}, {2, 3, 4}}, windows)
+
+	assert.Empty(t, slices.Collect(Window(slices.Values([]int{1, 2}), 3)))
+
+	var pulled int
+	averages := Map(Window(naturals(&pulled), 2), func(window []int) float64 {
+		return float64(window[0]+window[1]) / 2
+	})
+
+	assert.Equal(t, []float64{1.5, 2.5, 3.5}, slices.Collect(Take(averages, 3)))
+	assert.Equal(t, 4, pulled)
+}
+
+func TestZip(t *testing.T) {
+	var pulled int
+	letters := slices.Values([]string{"a", "b", "c"})
+
+	var pairs []string
+	for number, letter := range Zip(naturals(&pulled), letters) {
+		pairs = append(pairs, strconv.Itoa(number)+letter)
+	}
+
+	assert.Equal(t, []string{"1a", "2b", "3c"}, pairs)
+	assert.Equal(t, 4, pulled)
+
+	pairs = nil
+	for letter, number := range Zip(letters, naturals(&pulled)) {
+		pairs = append(pairs, letter+strconv.Itoa(number))
+		if len(pairs) == 2 {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"a1", "b2"}, pairs)
+}
+
+func TestFlatMap(t *testing.T) {
+	words := slices.Values([]string{"go", "", "lang"})
+	letters := FlatMap(words, func(word string) iter.Seq[string] {
+		return slices.Values(strings.Split(word, ""))
+	})
+
+	assert.Equal(t, []string{"g", "o", "l", "a", "n", "g"}, slices.Collect(letters))
+	assert.Equal(t, []string{"g", "o", "l"}, slices.Collect(Take(letters, 3)))
+
+	var pulled int
+	repeated := FlatMap(naturals(&pulled), func(number int) iter.Seq[int] {
+		return slices.Values(slices.Repeat([]int{number}, number))
+	})
+
+	assert.Equal(t, []int{1, 2, 2, 3}, slices.Collect(Take(repeated, 4)))
+	assert.Equal(t, 3, pulled)
+}
@@ -0,0 +1,230 @@
+package gc
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCycleInProgress   = errors.New("collection cycle already in progress")
+	ErrNoCycleInProgress = errors.New("no collection cycle in progress")
+)
+
+type CycleStats struct {
+	MarkedObjects int
+	MarkedBytes   int
+	FreedObjects  int
+	FreedBytes    int
+	// Steps counts mutator stops of the cycle, it's 1 for stop-the-world cycles
+	Steps    int
+	Pause    time.Duration
+	MaxPause time.Duration
+}
+
+type Stats struct {
+	Cycles          int
+	LiveObjects     int
+	LiveBytes       int
+	FreeListLength  int
+	TotalFreedBytes int
+	TotalPause      time.Duration
+	LastCycle       CycleStats
+}
+
+func (h *Heap) Stats() Stats {
+	stats := h.stats
+	stats.LiveObjects = h.liveObjects
+	stats.LiveBytes = h.liveBytes
+	stats.FreeListLength = len(h.freeList)
+	return stats
+}
+
+func (h *Heap) Marking() bool {
+	return h.marking
+}
+
+// Collect runs a stop-the-world cycle: marks everything
+// reachable from the roots and sweeps the rest into the free list,
+// a cycle started with StartCycle is finished instead
+func (h *Heap) Collect() CycleStats {
+	start := time.Now()
+	if !h.marking {
+		h.startCycle()
+	}
+
+	h.markTermination()
+	h.pause(start)
+	return h.endCycle()
+}
+
+// StartCycle shades the roots and enables the write barrier,
+// the graph can be mutated between the following MarkStep calls
+func (h *Heap) StartCycle() error {
+	if h.marking {
+		return ErrCycleInProgress
+	}
+
+	start := time.Now()
+	h.startCycle()
+	h.pause(start)
+	return nil
+}
+
+// MarkStep scans at most budget grey objects and reports whether marking is done
+func (h *Heap) MarkStep(budget int) bool {
+	if !h.marking {
+		return true
+	}
+
+	start := time.Now()
+	for ; budget > 0 && len(h.greyObjects) > 0; budget-- {
+		h.scan(h.popGrey())
+	}
+
+	h.pause(start)
+	return len(h.greyObjects) == 0
+}
+
+// FinishCycle drains the remaining grey objects and sweeps
+func (h *Heap) FinishCycle() (CycleStats, error) {
+	if !h.marking {
+		return CycleStats{}, ErrNoCycleInProgress
+	}
+
+	start := time.Now()
+	h.markTermination()
+	h.pause(start)
+	return h.endCycle(), nil
+}
+
+func (h *Heap) startCycle() {
+	h.marking = true
+	h.cycle = CycleStats{}
+
+	// roots and slots are pushed in reverse, so the first ones are scanned first
+	roots := h.uniqueRoots()
+	for index := len(roots) - 1; index >= 0; index-- {
+		h.shade(roots[index])
+	}
+}
+
+func (h *Heap) markTermination() {
+	for len(h.greyObjects) > 0 {
+		h.scan(h.popGrey())
+	}
+
+	h.sweep()
+	h.marking = false
+}
+
+func (h *Heap) endCycle() CycleStats {
+	cycle := h.cycle
+	h.stats.Cycles++
+	h.stats.TotalFreedBytes += cycle.FreedBytes
+	h.stats.TotalPause += cycle.Pause
+	h.stats.LastCycle = cycle
+	return cycle
+}
+
+func (h *Heap) pause(start time.Time) {
+	elapsed := time.Since(start)
+	h.cycle.Steps++
+	h.cycle.Pause += elapsed
+	h.cycle.MaxPause = max(h.cycle.MaxPause, elapsed)
+}
+
+func (h *Heap) uniqueRoots() []ObjectID {
+	seen := make(map[ObjectID]struct{}, len(h.roots))
+	roots := make([]ObjectID, 0, len(h.roots))
+	for _, root := range h.roots {
+		if _, ok := seen[root]; !ok {
+			seen[root] = struct{}{}
+			roots = append(roots, root)
+		}
+	}
+
+	return roots
+}
+
+// Trace returns objects reachable from the roots in the order they're marked,
+// it doesn't free anything and doesn't touch colors of an active cycle
+func (h *Heap) Trace() []ObjectID {
+	roots := h.uniqueRoots()
+
+	seen := make(map[ObjectID]struct{}, len(roots))
+	stack := make([]ObjectID, 0, len(roots))
+	for index := len(roots) - 1; index >= 0; index-- {
+		seen[roots[index]] = struct{}{}
+		stack = append(stack, roots[index])
+	}
+
+	var order []ObjectID
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, id)
+
+		pointers := h.objects[id].pointers
+		for index := len(pointers) - 1; index >= 0; index-- {
+			pointer := pointers[index]
+			if _, ok := seen[pointer]; pointer != Nil && !ok {
+				seen[pointer] = struct{}{}
+				stack = append(stack, pointer)
+			}
+		}
+	}
+
+	return order
+}
+
+func (h *Heap) shade(id ObjectID) {
+	obj := &h.objects[id]
+	if obj.color != white {
+		return
+	}
+
+	obj.color = grey
+	h.greyObjects = append(h.greyObjects, id)
+}
+
+func (h *Heap) popGrey() ObjectID {
+	id := h.greyObjects[len(h.greyObjects)-1]
+	h.greyObjects = h.greyObjects[:len(h.greyObjects)-1]
+	return id
+}
+
+func (h *Heap) scan(id ObjectID) {
+	obj := &h.objects[id]
+	for index := len(obj.pointers) - 1; index >= 0; index-- {
+		if pointer := obj.pointers[index]; pointer != Nil {
+			h.shade(pointer)
+		}
+	}
+
+	obj.color = black
+	h.cycle.MarkedObjects++
+	h.cycle.MarkedBytes += obj.size
+}
+
+func (h *Heap) sweep() {
+	for id := 1; id < len(h.objects); id++ {
+		obj := &h.objects[id]
+		if obj.free {
+			continue
+		}
+
+		if obj.color == black {
+			obj.color = white
+			continue
+		}
+
+		h.cycle.FreedObjects++
+		h.cycle.FreedBytes += obj.size
+		h.liveObjects--
+		h.liveBytes -= obj.size
+
+		obj.free = true
+		obj.pointers = obj.pointers[:0]
+		h.freeList = append(h.freeList, ObjectID(id))
+	}
+}
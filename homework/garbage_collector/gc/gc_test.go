@@ -0,0 +1,326 @@
+package gc
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v
+
+func allocate(t *testing.T, heap *Heap, size int, slots int) ObjectID {
+	t.Helper()
+
+	id, err := heap.Allocate(size, slots)
+	require.NoError(t, err)
+	return id
+}
+
+func link(t *testing.T, heap *Heap, from ObjectID, slot int, to ObjectID) {
+	t.Helper()
+	require.NoError(t, heap.SetPointer(from, slot, to))
+}
+
+func TestCollect(t *testing.T) {
+	heap := NewHeap()
+
+	root := allocate(t, heap, 16, 2)
+	child := allocate(t, heap, 32, 1)
+	grandchild := allocate(t, heap, 64, 0)
+	garbage := allocate(t, heap, 128, 1)
+	garbageCycle1 := allocate(t, heap, 8, 1)
+	garbageCycle2 := allocate(t, heap, 8, 1)
+
+	link(t, heap, root, 0, child)
+	link(t, heap, root, 1, child)
+	link(t, heap, child, 0, grandchild)
+	link(t, heap, garbage, 0, child)
+	link(t, heap, garbageCycle1, 0, garbageCycle2)
+	link(t, heap, garbageCycle2, 0, garbageCycle1)
+	require.NoError(t, heap.AddRoot(root))
+
+	cycle := heap.Collect()
+	assert.Equal(t, 3, cycle.MarkedObjects)
+	assert.Equal(t, 16+32+64, cycle.MarkedBytes)
+	assert.Equal(t, 3, cycle.FreedObjects)
+	assert.Equal(t, 128+8+8, cycle.FreedBytes)
+
+	for _, id := range []ObjectID{root, child, grandchild} {
+		assert.True(t, heap.IsLive(id))
+	}
+
+	for _, id := range []ObjectID{garbage, garbageCycle1, garbageCycle2} {
+		assert.False(t, heap.IsLive(id))
+	}
+
+	stats := heap.Stats()
+	assert.Equal(t, 1, stats.Cycles)
+	assert.Equal(t, 3, stats.LiveObjects)
+	assert.Equal(t, 16+32+64, stats.LiveBytes)
+	assert.Equal(t, 3, stats.FreeListLength)
+	assert.Equal(t, 128+8+8, stats.TotalFreedBytes)
+	assert.Equal(t, cycle, stats.LastCycle)
+
+	// nothing new became garbage, the second cycle frees nothing
+	cycle = heap.Collect()
+	assert.Equal(t, 3, cycle.MarkedObjects)
+	assert.Zero(t, cycle.FreedObjects)
+
+	require.True(t, heap.RemoveRoot(root))
+	cycle = heap.Collect()
+	assert.Zero(t, cycle.MarkedObjects)
+	assert.Equal(t, 3, cycle.FreedObjects)
+	assert.Equal(t, 0, heap.Stats().LiveBytes)
+	assert.Equal(t, 6, heap.Stats().FreeListLength)
+}
+
+func TestFreeListReuse(t *testing.T) {
+	heap := NewHeap()
+
+	root := allocate(t, heap, 8, 1)
+	garbage := allocate(t, heap, 8, 4)
+	link(t, heap, garbage, 3, root)
+	require.NoError(t, heap.AddRoot(root))
+
+	heap.Collect()
+	require.False(t, heap.IsLive(garbage))
+
+	reused := allocate(t, heap, 24, 2)
+	assert.Equal(t, garbage, reused)
+	assert.Equal(t, 0, heap.Stats().FreeListLength)
+
+	size, err := heap.Size(reused)
+	require.NoError(t, err)
+	assert.Equal(t, 24, size)
+
+	pointer, err := heap.Pointer(reused, 1)
+	require.NoError(t, err)
+	assert.Equal(t, Nil, pointer)
+
+	_, err = heap.Pointer(reused, 3)
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+}
+
+func TestTrace(t *testing.T) {
+	heap := NewHeap()
+
+	objects := make([]ObjectID, 6)
+	for index := range objects {
+		objects[index] = allocate(t, heap, 8, 2)
+	}
+
+	link(t, heap, objects[0], 0, objects[1])
+	link(t, heap, objects[0], 1, objects[2])
+	link(t, heap, objects[1], 0, objects[3])
+	link(t, heap, objects[3], 0, objects[0])
+	link(t, heap, objects[4], 0, objects[2])
+	require.NoError(t, heap.AddRoot(objects[0]))
+	require.NoError(t, heap.AddRoot(objects[4]))
+	require.NoError(t, heap.AddRoot(objects[0]))
+
+	expected := []ObjectID{objects[0], objects[1], objects[3], objects[2], objects[4]}
+	assert.Equal(t, expected, heap.Trace())
+	assert.Equal(t, expected, heap.Trace())
+
+	// tracing doesn't free anything
+	assert.True(t, heap.IsLive(objects[5]))
+	assert.Equal(t, 1, heap.Collect().FreedObjects)
+}
+
+func TestErrors(t *testing.T) {
+	heap := NewHeap()
+
+	_, err := heap.Allocate(0, 1)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	id := allocate(t, heap, 8, 1)
+	assert.ErrorIs(t, heap.SetPointer(id, 1, Nil), ErrInvalidSlot)
+	assert.ErrorIs(t, heap.SetPointer(id, 0, ObjectID(42)), ErrInvalidObject)
+	assert.ErrorIs(t, heap.SetPointer(Nil, 0, id), ErrInvalidObject)
+	assert.ErrorIs(t, heap.AddRoot(Nil), ErrInvalidObject)
+	assert.False(t, heap.RemoveRoot(id))
+
+	heap.Collect()
+	assert.ErrorIs(t, heap.AddRoot(id), ErrInvalidObject)
+	_, err = heap.Size(id)
+	assert.ErrorIs(t, err, ErrInvalidObject)
+}
+
+func TestIncrementalMarkingWithBarriers(t *testing.T) {
+	tests := map[string]struct {
+		barrier  Barrier
+		survives bool
+	}{
+		"hybrid barrier": {
+			barrier:  HybridBarrier,
+			survives: true,
+		},
+		"dijkstra barrier": {
+			barrier:  DijkstraBarrier,
+			survives: true,
+		},
+		"yuasa barrier": {
+			barrier:  YuasaBarrier,
+			survives: true,
+		},
+		"without barrier": {
+			barrier:  NoBarrier,
+			survives: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			heap := NewHeap(WithBarrier(test.barrier))
+
+			root := allocate(t, heap, 8, 2)
+			black := allocate(t, heap, 8, 1)
+			grey := allocate(t, heap, 8, 1)
+			white := allocate(t, heap, 8, 0)
+
+			link(t, heap, root, 0, black)
+			link(t, heap, root, 1, grey)
+			link(t, heap, grey, 0, white)
+			require.NoError(t, heap.AddRoot(root))
+
+			require.NoError(t, heap.StartCycle())
+			assert.False(t, heap.MarkStep(2))
+
+			// the black object takes the only reference to the white one away from the grey one
+			link(t, heap, black, 0, white)
+			link(t, heap, grey, 0, Nil)
+
+			cycle, err := heap.FinishCycle()
+			require.NoError(t, err)
+			assert.Equal(t, test.survives, heap.IsLive(white))
+			assert.Equal(t, 3, cycle.Steps)
+
+			if test.survives {
+				assert.Zero(t, cycle.FreedObjects)
+			} else {
+				assert.Equal(t, 1, cycle.FreedObjects)
+			}
+		})
+	}
+}
+
+func TestAllocationDuringMarking(t *testing.T) {
+	heap := NewHeap()
+
+	root := allocate(t, heap, 8, 1)
+	require.NoError(t, heap.AddRoot(root))
+
+	require.NoError(t, heap.StartCycle())
+	assert.True(t, heap.MarkStep(10))
+
+	attached := allocate(t, heap, 16, 0)
+	floating := allocate(t, heap, 32, 0)
+	link(t, heap, root, 0, attached)
+
+	cycle, err := heap.FinishCycle()
+	require.NoError(t, err)
+	assert.Zero(t, cycle.FreedObjects)
+	assert.True(t, heap.IsLive(attached))
+	assert.True(t, heap.IsLive(floating))
+
+	// floating garbage is collected by the next cycle
+	cycle = heap.Collect()
+	assert.Equal(t, 1, cycle.FreedObjects)
+	assert.Equal(t, 32, cycle.FreedBytes)
+	assert.True(t, heap.IsLive(attached))
+	assert.False(t, heap.IsLive(floating))
+}
+
+func TestRootChangesDuringMarking(t *testing.T) {
+	heap := NewHeap(WithBarrier(DijkstraBarrier))
+
+	root := allocate(t, heap, 8, 0)
+	detached := allocate(t, heap, 8, 0)
+	require.NoError(t, heap.AddRoot(root))
+
+	require.NoError(t, heap.StartCycle())
+	require.NoError(t, heap.AddRoot(detached))
+	require.True(t, heap.RemoveRoot(root))
+
+	cycle := heap.Collect()
+	assert.Zero(t, cycle.FreedObjects)
+	assert.True(t, heap.IsLive(detached))
+
+	cycle = heap.Collect()
+	assert.Equal(t, 1, cycle.FreedObjects)
+	assert.False(t, heap.IsLive(root))
+}
+
+func TestCycleErrors(t *testing.T) {
+	heap := NewHeap()
+
+	_, err := heap.FinishCycle()
+	assert.ErrorIs(t, err, ErrNoCycleInProgress)
+	assert.True(t, heap.MarkStep(1))
+
+	require.NoError(t, heap.StartCycle())
+	assert.True(t, heap.Marking())
+	assert.ErrorIs(t, heap.StartCycle(), ErrCycleInProgress)
+
+	heap.Collect()
+	assert.False(t, heap.Marking())
+	assert.Equal(t, 1, heap.Stats().Cycles)
+}
+
+func TestIncrementalMarkingStress(t *testing.T) {
+	for _, barrier := range []Barrier{HybridBarrier, DijkstraBarrier, YuasaBarrier} {
+		r := rand.New(rand.NewSource(42))
+		heap := NewHeap(WithBarrier(barrier))
+
+		for index := 0; index < 64; index++ {
+			allocate(t, heap, 1+r.Intn(64), 1+r.Intn(3))
+		}
+
+		for round := 0; round < 30; round++ {
+			// roots can point to anything between cycles, that's how garbage gets resurrected
+			for index := 0; index < 4; index++ {
+				if id := ObjectID(1 + r.Intn(len(heap.objects)-1)); heap.IsLive(id) {
+					require.NoError(t, heap.AddRoot(id))
+				}
+			}
+
+			require.NoError(t, heap.StartCycle())
+			for !heap.MarkStep(1 + r.Intn(4)) {
+				for mutation := 0; mutation < 8; mutation++ {
+					// the mutator can only reach objects that are reachable right now
+					reachable := heap.Trace()
+					if len(reachable) == 0 {
+						break
+					}
+
+					from := reachable[r.Intn(len(reachable))]
+					to := reachable[r.Intn(len(reachable))]
+
+					switch r.Intn(5) {
+					case 0:
+						to = Nil
+					case 1:
+						if heap.Stats().LiveObjects < 256 {
+							to = allocate(t, heap, 1+r.Intn(64), 1+r.Intn(3))
+						}
+					case 2:
+						require.NoError(t, heap.AddRoot(to))
+						heap.RemoveRoot(heap.roots[r.Intn(len(heap.roots))])
+					}
+
+					slots := len(heap.objects[from].pointers)
+					require.NoError(t, heap.SetPointer(from, r.Intn(slots), to))
+				}
+			}
+
+			_, err := heap.FinishCycle()
+			require.NoError(t, err)
+
+			for _, id := range heap.Trace() {
+				require.True(t, heap.IsLive(id), "reachable object %d was swept", id)
+			}
+		}
+	}
+}
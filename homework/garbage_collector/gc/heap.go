@@ -0,0 +1,215 @@
+package gc
+
+import (
+	"errors"
+	"slices"
+)
+
+var (
+	ErrInvalidObject = errors.New("invalid object")
+	ErrInvalidSlot   = errors.New("invalid pointer slot")
+	ErrInvalidSize   = errors.New("invalid object size")
+)
+
+// ObjectID addresses an object inside the simulated heap, Nil is a nil pointer
+type ObjectID uint32
+
+const Nil ObjectID = 0
+
+type color uint8
+
+const (
+	white color = iota // not reached yet, freed by the sweep if it stays white
+	grey               // reached, but pointer slots aren't scanned yet
+	black              // reached and scanned
+)
+
+// Barrier selects what SetPointer shades while marking is in progress
+type Barrier uint8
+
+const (
+	// HybridBarrier shades both the old and the new target like the Go runtime does
+	HybridBarrier Barrier = iota
+	// DijkstraBarrier shades the new target, so black objects never point to white ones
+	DijkstraBarrier
+	// YuasaBarrier shades the overwritten target, so everything reachable
+	// when the cycle started survives it
+	YuasaBarrier
+	// NoBarrier breaks incremental marking and exists to show why barriers are needed
+	NoBarrier
+)
+
+type Option func(*Heap)
+
+func WithBarrier(barrier Barrier) Option {
+	return func(heap *Heap) {
+		heap.barrier = barrier
+	}
+}
+
+type object struct {
+	size     int
+	pointers []ObjectID
+	color    color
+	free     bool
+}
+
+type Heap struct {
+	// objects[0] is a sentinel, so Nil never addresses a real object
+	objects  []object
+	freeList []ObjectID
+	roots    []ObjectID
+
+	liveObjects int
+	liveBytes   int
+
+	barrier     Barrier
+	marking     bool
+	greyObjects []ObjectID
+	cycle       CycleStats
+	stats       Stats
+}
+
+func NewHeap(options ...Option) *Heap {
+	heap := &Heap{
+		objects: make([]object, 1),
+	}
+
+	for _, option := range options {
+		option(heap)
+	}
+
+	return heap
+}
+
+// Allocate creates an object of the given size with slots nil pointers,
+// records of swept objects are reused from the free list first
+func (h *Heap) Allocate(size int, slots int) (ObjectID, error) {
+	if size <= 0 || slots < 0 {
+		return Nil, ErrInvalidSize
+	}
+
+	var id ObjectID
+	if length := len(h.freeList); length > 0 {
+		id = h.freeList[length-1]
+		h.freeList = h.freeList[:length-1]
+	} else {
+		h.objects = append(h.objects, object{})
+		id = ObjectID(len(h.objects) - 1)
+	}
+
+	obj := &h.objects[id]
+	obj.size = size
+	obj.pointers = slices.Grow(obj.pointers[:0], slots)[:slots]
+	clear(obj.pointers)
+	obj.color = white
+	obj.free = false
+
+	if h.marking {
+		// the scan may be already past objects that will point to the new one
+		obj.color = black
+	}
+
+	h.liveObjects++
+	h.liveBytes += size
+	return id, nil
+}
+
+func (h *Heap) SetPointer(from ObjectID, slot int, to ObjectID) error {
+	obj, err := h.object(from)
+	if err != nil {
+		return err
+	}
+
+	if slot < 0 || slot >= len(obj.pointers) {
+		return ErrInvalidSlot
+	}
+
+	if to != Nil {
+		if _, err := h.object(to); err != nil {
+			return err
+		}
+	}
+
+	if h.marking {
+		h.writeBarrier(obj.pointers[slot], to)
+	}
+
+	obj.pointers[slot] = to
+	return nil
+}
+
+func (h *Heap) writeBarrier(previous ObjectID, next ObjectID) {
+	if previous != Nil && (h.barrier == YuasaBarrier || h.barrier == HybridBarrier) {
+		h.shade(previous)
+	}
+
+	if next != Nil && (h.barrier == DijkstraBarrier || h.barrier == HybridBarrier) {
+		h.shade(next)
+	}
+}
+
+func (h *Heap) Pointer(from ObjectID, slot int) (ObjectID, error) {
+	obj, err := h.object(from)
+	if err != nil {
+		return Nil, err
+	}
+
+	if slot < 0 || slot >= len(obj.pointers) {
+		return Nil, ErrInvalidSlot
+	}
+
+	return obj.pointers[slot], nil
+}
+
+func (h *Heap) Size(id ObjectID) (int, error) {
+	obj, err := h.object(id)
+	if err != nil {
+		return 0, err
+	}
+
+	return obj.size, nil
+}
+
+func (h *Heap) IsLive(id ObjectID) bool {
+	_, err := h.object(id)
+	return err == nil
+}
+
+func (h *Heap) AddRoot(id ObjectID) error {
+	if _, err := h.object(id); err != nil {
+		return err
+	}
+
+	// roots aren't rescanned at the end of marking, so root changes go through the barrier too
+	if h.marking && h.barrier != NoBarrier {
+		h.shade(id)
+	}
+
+	h.roots = append(h.roots, id)
+	return nil
+}
+
+// RemoveRoot removes one occurrence of id, so an object added
+// as a root twice stays a root until it's removed twice
+func (h *Heap) RemoveRoot(id ObjectID) bool {
+	index := slices.Index(h.roots, id)
+	if index < 0 {
+		return false
+	}
+
+	if h.marking && h.barrier != NoBarrier {
+		h.shade(id)
+	}
+
+	h.roots = slices.Delete(h.roots, index, index+1)
+	return true
+}
+
+func (h *Heap) object(id ObjectID) (*object, error) {
+	if id == Nil || int(id) >= len(h.objects) || h.objects[id].free {
+		return nil, ErrInvalidObject
+	}
+
+	return &h.objects[id], nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"golang_course/homework/garbage_collector/retention"
+)
+
+// go run main.go -top 10 -min 1048576 heap.dump
+// the dump is written by debug.WriteHeapDump(file.Fd()) in the program being analyzed
+
+func main() {
+	top := flag.Int("top", 20, "number of roots to list")
+	minRetained := flag.Uint64("min", 1<<20, "skip dominator subtrees retaining fewer bytes")
+	address := flag.String("why", "", "print what keeps the object at this address alive")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("usage: retention [-top n] [-min bytes] [-why address] heap.dump")
+	}
+
+	if err := run(flag.Arg(0), *top, *minRetained, *address); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run returns errors instead of exiting, so the buffered output
+// written before an error is flushed by the deferred calls
+func run(path string, top int, minRetained uint64, address string) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	dump, err := retention.ReadDump(file)
+	if err != nil {
+		return err
+	}
+
+	report := retention.Analyze(dump)
+	output := bufio.NewWriter(os.Stdout)
+	defer func() {
+		if flushErr := output.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	if address != "" {
+		parsed, err := strconv.ParseUint(address, 0, 64)
+		if err != nil {
+			return err
+		}
+
+		chain, err := report.Dominators(parsed)
+		if err != nil {
+			return err
+		}
+
+		for depth, node := range chain {
+			fmt.Fprintf(output, "%*s%s: retained %d bytes\n", 2*depth, "", node.Name, node.Retained)
+		}
+
+		return nil
+	}
+
+	total := report.Total()
+	objects, bytes := report.Unreachable()
+	fmt.Fprintf(output, "reachable: %d bytes in %d objects, unreachable: %d bytes in %d objects\n\n",
+		total.Retained, total.RetainedObjects, bytes, objects)
+
+	for index, root := range report.Roots() {
+		if index == top {
+			break
+		}
+
+		fmt.Fprintf(output, "%12d %8d %s\n", root.Retained, root.RetainedObjects, root.Name)
+	}
+
+	fmt.Fprintln(output)
+	return report.WriteTree(output, minRetained)
+}
@@ -0,0 +1,111 @@
+package retention
+
+type graph struct {
+	successors   [][]int
+	predecessors [][]int
+}
+
+func newGraph(size int) *graph {
+	return &graph{
+		successors:   make([][]int, size),
+		predecessors: make([][]int, size),
+	}
+}
+
+func (g *graph) addEdge(from int, to int) {
+	g.successors[from] = append(g.successors[from], to)
+	g.predecessors[to] = append(g.predecessors[to], from)
+}
+
+// reversePostorder returns nodes reachable from start, the walk is iterative
+// because long linked lists in real heaps would overflow the stack
+func (g *graph) reversePostorder(start int) []int {
+	type frame struct {
+		node int
+		next int
+	}
+
+	visited := make([]bool, len(g.successors))
+	visited[start] = true
+
+	var order []int
+	stack := []frame{{node: start}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next == len(g.successors[top.node]) {
+			order = append(order, top.node)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		successor := g.successors[top.node][top.next]
+		top.next++
+		if !visited[successor] {
+			visited[successor] = true
+			stack = append(stack, frame{node: successor})
+		}
+	}
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order
+}
+
+// dominators computes immediate dominators with the iterative algorithm
+// by Cooper, Harvey and Kennedy, unreachable nodes stay undefined
+func (g *graph) dominators(order []int) []int {
+	// postorder numbers, so dominators have bigger numbers than nodes they dominate
+	number := make([]int, len(g.successors))
+	for index, node := range order {
+		number[node] = len(order) - index
+	}
+
+	dominators := make([]int, len(g.successors))
+	for node := range dominators {
+		dominators[node] = undefined
+	}
+
+	start := order[0]
+	dominators[start] = start
+
+	intersect := func(a int, b int) int {
+		for a != b {
+			for number[a] < number[b] {
+				a = dominators[a]
+			}
+
+			for number[b] < number[a] {
+				b = dominators[b]
+			}
+		}
+
+		return a
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, node := range order[1:] {
+			dominator := undefined
+			for _, predecessor := range g.predecessors[node] {
+				if dominators[predecessor] == undefined {
+					continue
+				}
+
+				if dominator == undefined {
+					dominator = predecessor
+				} else {
+					dominator = intersect(predecessor, dominator)
+				}
+			}
+
+			if dominators[node] != dominator {
+				dominators[node] = dominator
+				changed = true
+			}
+		}
+	}
+
+	return dominators
+}
@@ -0,0 +1,473 @@
+package retention
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// record tags and field kinds of the format written by runtime/debug.WriteHeapDump
+const (
+	tagEOF             = 0
+	tagObject          = 1
+	tagOtherRoot       = 2
+	tagType            = 3
+	tagGoroutine       = 4
+	tagStackFrame      = 5
+	tagParams          = 6
+	tagFinalizer       = 7
+	tagItab            = 8
+	tagOSThread        = 9
+	tagMemStats        = 10
+	tagQueuedFinalizer = 11
+	tagData            = 12
+	tagBSS             = 13
+	tagDefer           = 14
+	tagPanic           = 15
+	tagMemProf         = 16
+	tagAllocSample     = 17
+
+	fieldKindEol   = 0
+	fieldKindPtr   = 1
+	fieldKindIface = 2
+	fieldKindEface = 3
+)
+
+const dumpHeader = "go1.7 heap dump\n"
+
+// memStatsFields is the number of integers in the memstats record: 24 counters, 256 pauses and NumGC
+const memStatsFields = 24 + 256 + 1
+
+var ErrInvalidHeader = errors.New("retention: not a heap dump")
+
+type Object struct {
+	Address uint64
+	Size    uint64
+	// Pointers holds non-nil pointer values stored in the object,
+	// they may point inside other objects or outside the heap
+	Pointers []uint64
+}
+
+type Root struct {
+	Name string
+	// Address is where the root pointer is stored, it's 0 for roots without a location
+	Address uint64
+	Target  uint64
+}
+
+type Dump struct {
+	PointerSize int
+	BigEndian   bool
+	Objects     []Object
+	Roots       []Root
+}
+
+type dumpReader struct {
+	reader *bufio.Reader
+	dump   *Dump
+	buffer []byte
+	// goroutine is the id of the goroutine the following stack frames belong to
+	goroutine uint64
+}
+
+// ReadDump parses a heap dump, object contents are reduced
+// to the pointers they hold, so the dump doesn't have to fit in memory
+func ReadDump(reader io.Reader) (*Dump, error) {
+	r := &dumpReader{
+		reader: bufio.NewReaderSize(reader, 64<<10),
+		dump:   &Dump{PointerSize: 8},
+	}
+
+	header := make([]byte, len(dumpHeader))
+	if _, err := io.ReadFull(r.reader, header); err != nil || string(header) != dumpHeader {
+		return nil, ErrInvalidHeader
+	}
+
+	for {
+		tag, err := r.uint()
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == tagEOF {
+			return r.dump, nil
+		}
+
+		if err := r.record(tag); err != nil {
+			return nil, fmt.Errorf("retention: record %d: %w", tag, err)
+		}
+	}
+}
+
+func (r *dumpReader) record(tag uint64) error {
+	switch tag {
+	case tagParams:
+		return r.params()
+	case tagObject:
+		return r.object()
+	case tagOtherRoot:
+		name, err := r.string()
+		if err != nil {
+			return err
+		}
+
+		target, err := r.uint()
+		if err != nil {
+			return err
+		}
+
+		r.addRoot(name, 0, target)
+		return nil
+	case tagData, tagBSS:
+		return r.segment(tag)
+	case tagGoroutine:
+		return r.goroutineRecord()
+	case tagStackFrame:
+		return r.stackFrame()
+	case tagFinalizer, tagQueuedFinalizer:
+		return r.finalizer(tag)
+	case tagType:
+		if err := r.skipUints(2); err != nil {
+			return err
+		}
+
+		if _, err := r.string(); err != nil {
+			return err
+		}
+
+		return r.skipUints(1)
+	case tagItab, tagAllocSample:
+		return r.skipUints(2)
+	case tagOSThread:
+		return r.skipUints(3)
+	case tagPanic:
+		return r.skipUints(6)
+	case tagDefer:
+		return r.skipUints(7)
+	case tagMemStats:
+		return r.skipUints(memStatsFields)
+	case tagMemProf:
+		return r.memProf()
+	default:
+		return errors.New("unknown tag")
+	}
+}
+
+func (r *dumpReader) params() error {
+	bigEndian, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	pointerSize, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	if pointerSize != 4 && pointerSize != 8 {
+		return fmt.Errorf("unsupported pointer size %d", pointerSize)
+	}
+
+	r.dump.BigEndian = bigEndian != 0
+	r.dump.PointerSize = int(pointerSize)
+
+	// arena bounds, GOARCH, version and number of CPUs
+	if err := r.skipUints(2); err != nil {
+		return err
+	}
+
+	for range 2 {
+		if _, err := r.string(); err != nil {
+			return err
+		}
+	}
+
+	return r.skipUints(1)
+}
+
+func (r *dumpReader) object() error {
+	address, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	contents, err := r.bytes()
+	if err != nil {
+		return err
+	}
+
+	pointers, err := r.pointers(contents, nil)
+	if err != nil {
+		return err
+	}
+
+	r.dump.Objects = append(r.dump.Objects, Object{
+		Address:  address,
+		Size:     uint64(len(contents)),
+		Pointers: pointers,
+	})
+
+	return nil
+}
+
+func (r *dumpReader) segment(tag uint64) error {
+	address, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	contents, err := r.bytes()
+	if err != nil {
+		return err
+	}
+
+	name := "data"
+	if tag == tagBSS {
+		name = "bss"
+	}
+
+	return r.roots(contents, func(offset uint64) (string, uint64) {
+		return fmt.Sprintf("%s+0x%x", name, offset), address + offset
+	})
+}
+
+func (r *dumpReader) goroutineRecord() error {
+	// address and stack pointer go before the id
+	if err := r.skipUints(2); err != nil {
+		return err
+	}
+
+	id, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	r.goroutine = id
+
+	// creation pc, status, system and background flags, wait time
+	if err := r.skipUints(5); err != nil {
+		return err
+	}
+
+	if _, err := r.string(); err != nil {
+		return err
+	}
+
+	// context, m, defer and panic records
+	return r.skipUints(4)
+}
+
+func (r *dumpReader) stackFrame() error {
+	sp, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	// depth and stack pointer of the child frame
+	if err := r.skipUints(2); err != nil {
+		return err
+	}
+
+	contents, err := r.bytes()
+	if err != nil {
+		return err
+	}
+
+	// contents are reused by the next read, so they're copied before the function name is read
+	frame := append([]byte(nil), contents...)
+
+	// entry, pc and continuation pc
+	if err := r.skipUints(3); err != nil {
+		return err
+	}
+
+	function, err := r.string()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("goroutine %d: %s", r.goroutine, function)
+	return r.roots(frame, func(offset uint64) (string, uint64) {
+		return name, sp + offset
+	})
+}
+
+func (r *dumpReader) finalizer(tag uint64) error {
+	object, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	function, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	// code pointer and types of the argument and the object
+	if err := r.skipUints(3); err != nil {
+		return err
+	}
+
+	// a set finalizer keeps only its closure alive, a queued one is going to run with the object
+	if tag == tagQueuedFinalizer {
+		r.addRoot("queued finalizer", 0, object)
+		r.addRoot("queued finalizer", 0, function)
+	} else {
+		r.addRoot("finalizer", 0, function)
+	}
+
+	return nil
+}
+
+func (r *dumpReader) memProf() error {
+	// bucket and size
+	if err := r.skipUints(2); err != nil {
+		return err
+	}
+
+	frames, err := r.uint()
+	if err != nil {
+		return err
+	}
+
+	for range frames {
+		// function and file names followed by a line number
+		for range 2 {
+			if _, err := r.string(); err != nil {
+				return err
+			}
+		}
+
+		if err := r.skipUints(1); err != nil {
+			return err
+		}
+	}
+
+	// allocs and frees
+	return r.skipUints(2)
+}
+
+func (r *dumpReader) roots(contents []byte, locate func(offset uint64) (string, uint64)) error {
+	return r.fields(contents, func(offset uint64, pointer uint64) {
+		name, address := locate(offset)
+		r.addRoot(name, address, pointer)
+	})
+}
+
+func (r *dumpReader) addRoot(name string, address uint64, target uint64) {
+	if target == 0 {
+		return
+	}
+
+	r.dump.Roots = append(r.dump.Roots, Root{Name: name, Address: address, Target: target})
+}
+
+func (r *dumpReader) pointers(contents []byte, pointers []uint64) ([]uint64, error) {
+	err := r.fields(contents, func(_ uint64, pointer uint64) {
+		pointers = append(pointers, pointer)
+	})
+
+	return pointers, err
+}
+
+// fields reads a field list and calls visit for every non-nil pointer in contents
+func (r *dumpReader) fields(contents []byte, visit func(offset uint64, pointer uint64)) error {
+	for {
+		kind, err := r.uint()
+		if err != nil {
+			return err
+		}
+
+		if kind == fieldKindEol {
+			return nil
+		}
+
+		offset, err := r.uint()
+		if err != nil {
+			return err
+		}
+
+		// interfaces keep the data pointer in their second word
+		switch kind {
+		case fieldKindPtr:
+		case fieldKindIface, fieldKindEface:
+			offset += uint64(r.dump.PointerSize)
+		default:
+			return fmt.Errorf("unknown field kind %d", kind)
+		}
+
+		if offset+uint64(r.dump.PointerSize) > uint64(len(contents)) {
+			return fmt.Errorf("field offset %d out of range", offset)
+		}
+
+		if pointer := r.pointer(contents[offset:]); pointer != 0 {
+			visit(offset, pointer)
+		}
+	}
+}
+
+func (r *dumpReader) pointer(data []byte) uint64 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if r.dump.BigEndian {
+		order = binary.BigEndian
+	}
+
+	if r.dump.PointerSize == 4 {
+		return uint64(order.Uint32(data))
+	}
+
+	return order.Uint64(data)
+}
+
+func (r *dumpReader) uint() (uint64, error) {
+	value, err := binary.ReadUvarint(r.reader)
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return value, err
+}
+
+func (r *dumpReader) skipUints(count int) error {
+	for range count {
+		if _, err := r.uint(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bytes returns a length-prefixed byte string, it's valid until the next call
+func (r *dumpReader) bytes() ([]byte, error) {
+	length, err := r.uint()
+	if err != nil {
+		return nil, err
+	}
+
+	if length > 1<<40 {
+		return nil, fmt.Errorf("invalid length %d", length)
+	}
+
+	if uint64(cap(r.buffer)) < length {
+		r.buffer = make([]byte, length)
+	}
+
+	r.buffer = r.buffer[:length]
+	if _, err := io.ReadFull(r.reader, r.buffer); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return r.buffer, nil
+}
+
+func (r *dumpReader) string() (string, error) {
+	data, err := r.bytes()
+	return string(data), err
+}
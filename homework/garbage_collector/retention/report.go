@@ -0,0 +1,233 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownAddress = errors.New("retention: address doesn't belong to any object")
+	ErrUnreachable    = errors.New("retention: object isn't reachable from roots")
+)
+
+// superRoot is a virtual node pointing to every root, so the graph has a single entry
+const superRoot = 0
+
+const undefined = -1
+
+type Node struct {
+	Name    string
+	Address uint64
+	Size    uint64
+	// Retained is the number of bytes that would be freed if nothing else referenced the node,
+	// it's the size of the node together with everything it dominates
+	Retained        uint64
+	RetainedObjects int
+	Root            bool
+}
+
+type Report struct {
+	// nodes[0] is the super root, roots go next and objects follow them sorted by address
+	nodes       []Node
+	firstObject int
+	dominators  []int
+	children    [][]int
+
+	unreachableObjects int
+	unreachableBytes   uint64
+}
+
+// Analyze builds the dominator tree of the dump: every node is a child of its immediate dominator,
+// the closest node that every path from the roots goes through, and retains its whole subtree
+func Analyze(dump *Dump) *Report {
+	objects := slices.Clone(dump.Objects)
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Address < objects[j].Address
+	})
+
+	report := &Report{
+		nodes:       make([]Node, 1, 1+len(dump.Roots)+len(objects)),
+		firstObject: 1 + len(dump.Roots),
+	}
+
+	report.nodes[superRoot] = Node{Name: "roots"}
+	for _, root := range dump.Roots {
+		report.nodes = append(report.nodes, Node{Name: root.Name, Address: root.Address, Root: true})
+	}
+
+	for _, object := range objects {
+		report.nodes = append(report.nodes, Node{
+			Name:    fmt.Sprintf("object 0x%x", object.Address),
+			Address: object.Address,
+			Size:    object.Size,
+		})
+	}
+
+	graph := newGraph(len(report.nodes))
+	for index := range dump.Roots {
+		graph.addEdge(superRoot, 1+index)
+	}
+
+	for index, root := range dump.Roots {
+		if target, ok := report.find(root.Target); ok {
+			graph.addEdge(1+index, target)
+		}
+	}
+
+	for index, object := range objects {
+		for _, pointer := range object.Pointers {
+			if target, ok := report.find(pointer); ok {
+				graph.addEdge(report.firstObject+index, target)
+			}
+		}
+	}
+
+	order := graph.reversePostorder(superRoot)
+	report.dominators = graph.dominators(order)
+	report.retain(order)
+	return report
+}
+
+// find returns the node of the object containing address, pointers to the middle of objects keep them alive too
+func (r *Report) find(address uint64) (int, bool) {
+	objects := r.nodes[r.firstObject:]
+	index := sort.Search(len(objects), func(i int) bool {
+		return objects[i].Address+objects[i].Size > address
+	})
+
+	if index == len(objects) || objects[index].Address > address {
+		return 0, false
+	}
+
+	return r.firstObject + index, true
+}
+
+func (r *Report) retain(order []int) {
+	for _, node := range order {
+		r.nodes[node].Retained = r.nodes[node].Size
+		if node >= r.firstObject {
+			r.nodes[node].RetainedObjects = 1
+		}
+	}
+
+	// dominators go before the nodes they dominate in reverse postorder
+	for index := len(order) - 1; index > 0; index-- {
+		node := order[index]
+		dominator := r.dominators[node]
+		r.nodes[dominator].Retained += r.nodes[node].Retained
+		r.nodes[dominator].RetainedObjects += r.nodes[node].RetainedObjects
+	}
+
+	r.children = make([][]int, len(r.nodes))
+	for _, node := range order[1:] {
+		dominator := r.dominators[node]
+		r.children[dominator] = append(r.children[dominator], node)
+	}
+
+	for _, children := range r.children {
+		r.sortByRetained(children)
+	}
+
+	for node := r.firstObject; node < len(r.nodes); node++ {
+		if r.dominators[node] == undefined {
+			r.unreachableObjects++
+			r.unreachableBytes += r.nodes[node].Size
+		}
+	}
+}
+
+func (r *Report) sortByRetained(nodes []int) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return r.nodes[nodes[i]].Retained > r.nodes[nodes[j]].Retained
+	})
+}
+
+// Total returns the super root node, it retains everything reachable
+func (r *Report) Total() Node {
+	return r.nodes[superRoot]
+}
+
+// Roots returns roots retaining at least one object, the biggest first,
+// objects shared by several roots aren't retained by any of them
+func (r *Report) Roots() []Node {
+	var roots []Node
+	for _, node := range r.children[superRoot] {
+		if r.nodes[node].Root && r.nodes[node].RetainedObjects > 0 {
+			roots = append(roots, r.nodes[node])
+		}
+	}
+
+	return roots
+}
+
+// Object returns the object containing address
+func (r *Report) Object(address uint64) (Node, error) {
+	node, ok := r.find(address)
+	if !ok {
+		return Node{}, ErrUnknownAddress
+	}
+
+	return r.nodes[node], nil
+}
+
+// Dominators answers what keeps the object at address alive: it returns
+// its dominator chain starting with the root and ending with the object
+func (r *Report) Dominators(address uint64) ([]Node, error) {
+	node, ok := r.find(address)
+	if !ok {
+		return nil, ErrUnknownAddress
+	}
+
+	if r.dominators[node] == undefined {
+		return nil, ErrUnreachable
+	}
+
+	var chain []Node
+	for ; node != superRoot; node = r.dominators[node] {
+		chain = append(chain, r.nodes[node])
+	}
+
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// Unreachable returns objects present in the dump but not reachable from roots,
+// they are garbage the collector hasn't swept yet
+func (r *Report) Unreachable() (objects int, bytes uint64) {
+	return r.unreachableObjects, r.unreachableBytes
+}
+
+// WriteTree prints the dominator tree, subtrees retaining less than minRetained bytes are skipped
+func (r *Report) WriteTree(writer io.Writer, minRetained uint64) error {
+	type frame struct {
+		node  int
+		depth int
+	}
+
+	stack := []frame{{node: superRoot}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := r.nodes[current.node]
+		_, err := fmt.Fprintf(writer, "%s%s: retained %d bytes in %d objects\n",
+			strings.Repeat("  ", current.depth), node.Name, node.Retained, node.RetainedObjects)
+		if err != nil {
+			return err
+		}
+
+		children := r.children[current.node]
+		for index := len(children) - 1; index >= 0; index-- {
+			child := children[index]
+			if r.nodes[child].Retained >= minRetained && r.nodes[child].RetainedObjects > 0 {
+				stack = append(stack, frame{node: child, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return nil
+}
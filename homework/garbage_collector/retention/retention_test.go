@@ -0,0 +1,326 @@
+package retention
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+// dumpWriter builds synthetic dumps in the format of runtime/debug.WriteHeapDump
+type dumpWriter struct {
+	buffer bytes.Buffer
+}
+
+func newDumpWriter() *dumpWriter {
+	writer := &dumpWriter{}
+	writer.buffer.WriteString(dumpHeader)
+	writer.uints(tagParams, 0, 8, 0x1000, 0x100000)
+	writer.string("amd64")
+	writer.string("go1.23")
+	writer.uints(4)
+	return writer
+}
+
+func (w *dumpWriter) uints(values ...uint64) {
+	for _, value := range values {
+		w.buffer.Write(binary.AppendUvarint(nil, value))
+	}
+}
+
+func (w *dumpWriter) string(value string) {
+	w.uints(uint64(len(value)))
+	w.buffer.WriteString(value)
+}
+
+// words writes contents made of pointer-sized words followed by a field list marking every non-zero word
+func (w *dumpWriter) words(words ...uint64) {
+	w.contents(words...)
+	w.fields(words...)
+}
+
+func (w *dumpWriter) contents(words ...uint64) {
+	contents := make([]byte, 0, 8*len(words))
+	for _, word := range words {
+		contents = binary.LittleEndian.AppendUint64(contents, word)
+	}
+
+	w.uints(uint64(len(contents)))
+	w.buffer.Write(contents)
+}
+
+func (w *dumpWriter) fields(words ...uint64) {
+	for index, word := range words {
+		if word != 0 {
+			w.uints(fieldKindPtr, uint64(8*index))
+		}
+	}
+
+	w.uints(fieldKindEol)
+}
+
+func (w *dumpWriter) object(address uint64, words ...uint64) {
+	w.uints(tagObject, address)
+	w.words(words...)
+}
+
+func (w *dumpWriter) bytes() []byte {
+	w.uints(tagEOF)
+	return w.buffer.Bytes()
+}
+
+func TestReadDump(t *testing.T) {
+	writer := newDumpWriter()
+	writer.uints(tagType, 0x500, 16)
+	writer.string("main.node")
+	writer.uints(1)
+	writer.uints(tagItab, 0x600, 0x500)
+	writer.object(0x2000, 0x2010, 0, 0x3000)
+	writer.object(0x3000, 0)
+
+	writer.uints(tagGoroutine, 0xc000, 0x7000, 7, 0x401000, 4, 0, 0, 0)
+	writer.string("chan receive")
+	writer.uints(0, 0xd000, 0, 0)
+	writer.uints(tagStackFrame, 0x7000, 0, 0)
+	writer.contents(0, 0x2000)
+	writer.uints(0x401000, 0x401010, 0x401010)
+	writer.string("main.main")
+	writer.fields(0, 0x2000)
+	writer.uints(tagDefer, 0xe000, 0xc000, 0x7000, 0x401020, 0, 0, 0)
+	writer.uints(tagOSThread, 0xd000, 1, 100)
+
+	writer.uints(tagData, 0x9000)
+	writer.words(0, 0, 0x3008)
+	writer.uints(tagBSS, 0xa000)
+	writer.words(0x2000)
+	writer.uints(tagOtherRoot)
+	writer.string("runtime internal")
+	writer.uints(0x3000)
+	writer.uints(tagFinalizer, 0x3000, 0x4000, 0x401030, 0x500, 0x500)
+	writer.uints(tagQueuedFinalizer, 0x2000, 0, 0x401030, 0x500, 0x500)
+
+	writer.uints(tagMemStats)
+	writer.uints(make([]uint64, memStatsFields)...)
+	writer.uints(tagMemProf, 0xf000, 64, 1)
+	writer.string("main.main")
+	writer.string("main.go")
+	writer.uints(10, 1, 0)
+	writer.uints(tagAllocSample, 0x2000, 0xf000)
+
+	dump, err := ReadDump(bytes.NewReader(writer.bytes()))
+	require.NoError(t, err)
+
+	assert.Equal(t, 8, dump.PointerSize)
+	assert.False(t, dump.BigEndian)
+	assert.Equal(t, []Object{
+		{Address: 0x2000, Size: 24, Pointers: []uint64{0x2010, 0x3000}},
+		{Address: 0x3000, Size: 8},
+	}, dump.Objects)
+	assert.Equal(t, []Root{
+		{Name: "goroutine 7: main.main", Address: 0x7008, Target: 0x2000},
+		{Name: "data+0x10", Address: 0x9010, Target: 0x3008},
+		{Name: "bss+0x0", Address: 0xa000, Target: 0x2000},
+		{Name: "runtime internal", Target: 0x3000},
+		{Name: "finalizer", Target: 0x4000},
+		{Name: "queued finalizer", Target: 0x2000},
+	}, dump.Roots)
+}
+
+func TestReadDumpErrors(t *testing.T) {
+	_, err := ReadDump(strings.NewReader("go1.6 heap dump\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	writer := newDumpWriter()
+	writer.object(0x2000, 0x3000)
+	data := writer.bytes()
+
+	_, err = ReadDump(bytes.NewReader(data[:len(data)-4]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	writer = newDumpWriter()
+	writer.uints(99)
+	_, err = ReadDump(bytes.NewReader(writer.bytes()))
+	assert.ErrorContains(t, err, "unknown tag")
+
+	writer = newDumpWriter()
+	writer.uints(tagObject, 0x2000, 8)
+	writer.buffer.Write(make([]byte, 8))
+	writer.uints(fieldKindPtr, 8, fieldKindEol)
+	_, err = ReadDump(bytes.NewReader(writer.bytes()))
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestAnalyze(t *testing.T) {
+	// global -> a -> b -> c
+	//                \-> d <- stack
+	// cycle: e <-> f is only reachable from a
+	dump := &Dump{
+		Objects: []Object{
+			{Address: 0x100, Size: 16, Pointers: []uint64{0x200, 0x400, 0x500}},
+			{Address: 0x200, Size: 32, Pointers: []uint64{0x300}},
+			{Address: 0x300, Size: 64},
+			{Address: 0x400, Size: 128},
+			{Address: 0x500, Size: 8, Pointers: []uint64{0x600}},
+			{Address: 0x600, Size: 8, Pointers: []uint64{0x500}},
+			{Address: 0x700, Size: 1000},
+		},
+		Roots: []Root{
+			{Name: "bss+0x0", Address: 0x10, Target: 0x100},
+			{Name: "goroutine 1: main.main", Address: 0x20, Target: 0x400},
+			{Name: "data+0x8", Address: 0x30, Target: 0x50},
+		},
+	}
+
+	report := Analyze(dump)
+
+	total := report.Total()
+	assert.Equal(t, uint64(256), total.Retained)
+	assert.Equal(t, 6, total.RetainedObjects)
+
+	objects, bytes := report.Unreachable()
+	assert.Equal(t, 1, objects)
+	assert.Equal(t, uint64(1000), bytes)
+
+	roots := report.Roots()
+	require.Len(t, roots, 1)
+	assert.Equal(t, "bss+0x0", roots[0].Name)
+	// d is shared with the stack, so the global doesn't retain it
+	assert.Equal(t, uint64(16+32+64+8+8), roots[0].Retained)
+	assert.Equal(t, 5, roots[0].RetainedObjects)
+	assert.True(t, roots[0].Root)
+
+	object, err := report.Object(0x208)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x200), object.Address)
+	assert.Equal(t, uint64(96), object.Retained)
+
+	chain, err := report.Dominators(0x300)
+	require.NoError(t, err)
+	names := make([]string, 0, len(chain))
+	for _, node := range chain {
+		names = append(names, node.Name)
+	}
+
+	assert.Equal(t, []string{"bss+0x0", "object 0x100", "object 0x200", "object 0x300"}, names)
+
+	chain, err = report.Dominators(0x400)
+	require.NoError(t, err)
+	require.Len(t, chain, 1)
+	assert.Equal(t, uint64(0x400), chain[0].Address)
+
+	_, err = report.Dominators(0x700)
+	assert.ErrorIs(t, err, ErrUnreachable)
+
+	_, err = report.Dominators(0x1000)
+	assert.ErrorIs(t, err, ErrUnknownAddress)
+
+	_, err = report.Object(0x110)
+	assert.ErrorIs(t, err, ErrUnknownAddress)
+}
+
+func TestWriteTree(t *testing.T) {
+	dump := &Dump{
+		Objects: []Object{
+			{Address: 0x100, Size: 16, Pointers: []uint64{0x200, 0x300}},
+			{Address: 0x200, Size: 32},
+			{Address: 0x300, Size: 1 << 20},
+			{Address: 0x200000, Size: 64},
+		},
+		Roots: []Root{
+			{Name: "goroutine 1: main.main", Target: 0x200000},
+			{Name: "bss+0x8", Target: 0x100},
+		},
+	}
+
+	var output strings.Builder
+	require.NoError(t, Analyze(dump).WriteTree(&output, 64))
+
+	expected := "roots: retained 1048688 bytes in 4 objects\n" +
+		"  bss+0x8: retained 1048624 bytes in 3 objects\n" +
+		"    object 0x100: retained 1048624 bytes in 3 objects\n" +
+		"      object 0x300: retained 1048576 bytes in 1 objects\n" +
+		"  goroutine 1: main.main: retained 64 bytes in 1 objects\n" +
+		"    object 0x200000: retained 64 bytes in 1 objects\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func TestAnalyzeLongList(t *testing.T) {
+	const length = 1_000_000
+
+	dump := &Dump{
+		Objects: make([]Object, length),
+		Roots:   []Root{{Name: "bss+0x0", Target: 16}},
+	}
+
+	for index := range dump.Objects {
+		address := uint64(16 * (index + 1))
+		dump.Objects[index] = Object{Address: address, Size: 16}
+		if index+1 < length {
+			dump.Objects[index].Pointers = []uint64{address + 16}
+		}
+	}
+
+	report := Analyze(dump)
+	roots := report.Roots()
+	require.Len(t, roots, 1)
+	assert.Equal(t, uint64(16*length), roots[0].Retained)
+
+	chain, err := report.Dominators(16 * length)
+	require.NoError(t, err)
+	assert.Len(t, chain, length+1)
+}
+
+type retained struct {
+	next    *retained
+	payload []byte
+}
+
+var ballast *retained
+
+func TestAnalyzeRuntimeDump(t *testing.T) {
+	const size = 8 << 20
+
+	ballast = &retained{next: &retained{payload: make([]byte, size)}}
+	defer func() {
+		ballast = nil
+	}()
+
+	path := filepath.Join(t.TempDir(), "heap.dump")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	debug.WriteHeapDump(file.Fd())
+	require.NoError(t, file.Close())
+
+	file, err = os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	dump, err := ReadDump(file)
+	require.NoError(t, err)
+
+	report := Analyze(dump)
+	payload := uint64(uintptr(unsafe.Pointer(unsafe.SliceData(ballast.next.payload))))
+
+	chain, err := report.Dominators(payload)
+	require.NoError(t, err)
+	require.Len(t, chain, 4)
+
+	// the global variable is the only thing keeping the payload alive
+	assert.True(t, chain[0].Root)
+	assert.True(t, strings.HasPrefix(chain[0].Name, "bss+") || strings.HasPrefix(chain[0].Name, "data+"))
+	assert.Equal(t, uint64(uintptr(unsafe.Pointer(&ballast))), chain[0].Address)
+	assert.Equal(t, uint64(uintptr(unsafe.Pointer(ballast))), chain[1].Address)
+	assert.GreaterOrEqual(t, chain[0].Retained, uint64(size))
+	assert.GreaterOrEqual(t, report.Roots()[0].Retained, uint64(size))
+}
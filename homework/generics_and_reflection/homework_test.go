@@ -1,12 +1,12 @@
 package main
 
 import (
-	"fmt"
-	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"golang_course/homework/generics_and_reflection/properties"
 )
 
 // go test -v homework_test.go
@@ -19,36 +19,12 @@ type Person struct {
 }
 
 func Serialize(person interface{}) string {
-	personType := reflect.TypeOf(person)
-	personValue := reflect.ValueOf(person)
-
-	var result []string
-
-outer:
-	for i := 0; i < personType.NumField(); i++ {
-		props := strings.Split(personType.Field(i).Tag.Get("properties"), ",")
-		field := personValue.Field(i)
-
-		isZero := reflect.Zero(field.Type()).Interface() == field.Interface()
-
-		nameIndex := 0
-
-		for index, prop := range props {
-			if prop == "omitempty" {
-				if isZero {
-					continue outer
-				} else {
-					if index == nameIndex {
-						nameIndex++
-					}
-				}
-			}
-		}
-
-		result = append(result, props[nameIndex]+"="+fmt.Sprintf("%v", field.Interface()))
+	data, err := properties.Marshal(person)
+	if err != nil {
+		return ""
 	}
 
-	return strings.Join(result, "\n")
+	return strings.TrimSuffix(string(data), "\n")
 }
 
 func TestSerialization(t *testing.T) {
@@ -0,0 +1,86 @@
+package properties
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// go test -bench=. -benchmem
+
+type Record struct {
+	ID      int64   `properties:"id"`
+	Name    string  `properties:"name"`
+	Email   string  `properties:"email,omitempty"`
+	Age     int     `properties:"omitempty,age"`
+	Score   float64 `properties:"score"`
+	Married bool    `properties:"married"`
+}
+
+var record = Record{
+	ID:      42,
+	Name:    "John Doe",
+	Email:   "john@example.com",
+	Age:     30,
+	Score:   99.5,
+	Married: true,
+}
+
+var Sink string
+
+// serializeWithoutCache is the original homework implementation,
+// it parses tags and walks reflect.Type fields on every call
+func serializeWithoutCache(person interface{}) string {
+	personType := reflect.TypeOf(person)
+	personValue := reflect.ValueOf(person)
+
+	var result []string
+
+outer:
+	for i := 0; i < personType.NumField(); i++ {
+		props := strings.Split(personType.Field(i).Tag.Get("properties"), ",")
+		field := personValue.Field(i)
+
+		isZero := reflect.Zero(field.Type()).Interface() == field.Interface()
+
+		nameIndex := 0
+
+		for index, prop := range props {
+			if prop == "omitempty" {
+				if isZero {
+					continue outer
+				} else {
+					if index == nameIndex {
+						nameIndex++
+					}
+				}
+			}
+		}
+
+		result = append(result, props[nameIndex]+"="+fmt.Sprintf("%v", field.Interface()))
+	}
+
+	return strings.Join(result, "\n")
+}
+
+func BenchmarkSerializeWithoutCache(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Sink = serializeWithoutCache(record)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		data, _ := Marshal(&record)
+		Sink = string(data)
+	}
+}
+
+func BenchmarkEncoder(b *testing.B) {
+	encoder := NewEncoder(io.Discard)
+	for i := 0; i < b.N; i++ {
+		_ = encoder.Encode(&record)
+	}
+}
@@ -0,0 +1,515 @@
+package properties
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+var anyMapType = reflect.TypeFor[map[string]any]()
+
+var ErrUnknownKey = errors.New("unknown key")
+
+// MaxSliceIndex bounds slice indices in keys, so a single line
+// of untrusted input can't make the decoder allocate a huge slice
+const MaxSliceIndex = 1<<16 - 1
+
+type SyntaxError struct {
+	Line   int
+	Column int
+	msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("properties: line %d, column %d: %s", e.Line, e.Column, e.msg)
+}
+
+type DecodeError struct {
+	Line   int
+	Column int
+	Key    string
+	Type   reflect.Type
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("properties: line %d: %s: %v", e.Line, e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+type DecoderOption func(*Decoder)
+
+func DisallowUnknownKeys() DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.disallowUnknownKeys = true
+	}
+}
+
+type Decoder struct {
+	reader              *bufio.Reader
+	line                int
+	disallowUnknownKeys bool
+}
+
+func NewDecoder(reader io.Reader, options ...DecoderOption) *Decoder {
+	decoder := &Decoder{
+		reader: bufio.NewReader(reader),
+	}
+
+	for _, option := range options {
+		option(decoder)
+	}
+
+	return decoder
+}
+
+func Unmarshal(data []byte, v any, options ...DecoderOption) error {
+	return NewDecoder(bytes.NewReader(data), options...).Decode(v)
+}
+
+// Decode applies every line of the input to v as soon as it's read,
+// so slices and maps already present in v are merged with the input
+func (d *Decoder) Decode(v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return errors.New("properties: can't unmarshal into non-pointer or nil value")
+	}
+
+	for {
+		entry, err := d.next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := d.decode(&entry, value.Elem(), 0); err != nil {
+			return err
+		}
+	}
+}
+
+type entry struct {
+	key      string
+	segments []string
+	value    string
+	line     int
+	column   int
+}
+
+// piece maps an offset in a logical line back to the physical line it came from
+type piece struct {
+	offset int
+	line   int
+	column int
+}
+
+func position(pieces []piece, offset int) (line, column int) {
+	current := pieces[0]
+	for _, piece := range pieces[1:] {
+		if piece.offset > offset {
+			break
+		}
+
+		current = piece
+	}
+
+	return current.line, current.column + offset - current.offset
+}
+
+func (d *Decoder) next() (entry, error) {
+	for {
+		text, pieces, err := d.readLogicalLine()
+		if err != nil {
+			return entry{}, err
+		}
+
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+
+		rawKey, rawValue, ok := splitLine(text)
+		if !ok {
+			line, column := position(pieces, len(text))
+			return entry{}, &SyntaxError{Line: line, Column: column, msg: "missing '=' separator"}
+		}
+
+		segments, err := splitKey(rawKey)
+		if err != nil {
+			line, column := position(pieces, len(rawKey))
+			return entry{}, &SyntaxError{Line: line, Column: column, msg: err.Error()}
+		}
+
+		value, err := unescape(rawValue)
+		if err != nil {
+			line, column := position(pieces, len(text))
+			return entry{}, &SyntaxError{Line: line, Column: column, msg: err.Error()}
+		}
+
+		line, column := position(pieces, len(text)-len(rawValue))
+		return entry{
+			key:      rawKey,
+			segments: segments,
+			value:    value,
+			line:     line,
+			column:   column,
+		}, nil
+	}
+}
+
+// readLogicalLine joins physical lines ending with an odd number of backslashes,
+// leading whitespace of every physical line is dropped
+func (d *Decoder) readLogicalLine() (string, []piece, error) {
+	first, err := d.readLine()
+	if err != nil {
+		return "", nil, err
+	}
+
+	text := trimLeftSpace(first)
+	pieces := []piece{{offset: 0, line: d.line, column: len(first) - len(text) + 1}}
+
+	if text != "" && (text[0] == '#' || text[0] == '!') {
+		return text, pieces, nil
+	}
+
+	for continues(text) {
+		text = text[:len(text)-1]
+
+		next, err := d.readLine()
+		if err == io.EOF {
+			line, column := position(pieces, len(text))
+			return "", nil, &SyntaxError{Line: line, Column: column, msg: "unexpected end of input after line continuation"}
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		trimmed := trimLeftSpace(next)
+		pieces = append(pieces, piece{offset: len(text), line: d.line, column: len(next) - len(trimmed) + 1})
+		text += trimmed
+	}
+
+	return text, pieces, nil
+}
+
+func (d *Decoder) readLine() (string, error) {
+	line, err := d.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+
+	d.line++
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+func continues(text string) bool {
+	backslashes := 0
+	for index := len(text) - 1; index >= 0 && text[index] == '\\'; index-- {
+		backslashes++
+	}
+
+	return backslashes%2 == 1
+}
+
+func (d *Decoder) error(e *entry, t reflect.Type, err error) error {
+	return &DecodeError{
+		Line:   e.line,
+		Column: e.column,
+		Key:    e.key,
+		Type:   t,
+		Err:    err,
+	}
+}
+
+// decode walks value along the key segments starting from depth
+// and stores the entry value into whatever it ends at
+func (d *Decoder) decode(e *entry, value reflect.Value, depth int) error {
+	if depth == len(e.segments) {
+		return d.decodeValue(e, value)
+	}
+
+	segment := e.segments[depth]
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			if d.ignores(value.Type(), e.segments[depth:]) {
+				return nil
+			}
+
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+
+		return d.decode(e, value.Elem(), depth)
+	case reflect.Interface:
+		if !value.IsNil() && value.Elem().Kind() == reflect.Pointer {
+			return d.decode(e, value.Elem(), depth)
+		}
+
+		if value.NumMethod() != 0 {
+			return d.error(e, value.Type(), errors.New("can't decode into non-empty interface"))
+		}
+
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(anyMapType))
+		}
+
+		if value.Elem().Type() != anyMapType {
+			return d.error(e, value.Type(), fmt.Errorf("can't decode nested key into %s", value.Elem().Type()))
+		}
+
+		return d.decode(e, value.Elem(), depth)
+	case reflect.Struct:
+		field, ok := cachedTypeFields(value.Type()).lookup(segment)
+		if !ok {
+			if d.disallowUnknownKeys {
+				return d.error(e, value.Type(), ErrUnknownKey)
+			}
+
+			return nil
+		}
+
+		return d.decode(e, fieldByIndex(value, field.index), depth+1)
+	case reflect.Map:
+		mapKey, err := parseMapKey(segment, value.Type().Key())
+		if err != nil {
+			return d.error(e, value.Type().Key(), err)
+		}
+
+		if d.ignores(value.Type().Elem(), e.segments[depth+1:]) {
+			return nil
+		}
+
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+
+		// map elements aren't addressable, so they're decoded into a copy
+		elem := reflect.New(value.Type().Elem()).Elem()
+		if existing := value.MapIndex(mapKey); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		if err := d.decode(e, elem, depth+1); err != nil {
+			return err
+		}
+
+		value.SetMapIndex(mapKey, elem)
+	case reflect.Slice:
+		index, err := parseIndex(segment)
+		if err != nil {
+			return d.error(e, value.Type(), err)
+		}
+
+		if index >= value.Len() {
+			if index > MaxSliceIndex {
+				return d.error(e, value.Type(), fmt.Errorf("index %d exceeds maximum %d", index, MaxSliceIndex))
+			}
+
+			if d.ignores(value.Type().Elem(), e.segments[depth+1:]) {
+				return nil
+			}
+
+			growSlice(value, index+1)
+		}
+
+		return d.decode(e, value.Index(index), depth+1)
+	case reflect.Array:
+		index, err := parseIndex(segment)
+		if err != nil {
+			return d.error(e, value.Type(), err)
+		}
+
+		if index >= value.Len() {
+			return d.error(e, value.Type(), fmt.Errorf("index %d out of range", index))
+		}
+
+		return d.decode(e, value.Index(index), depth+1)
+	default:
+		return d.error(e, value.Type(), fmt.Errorf("can't decode nested key into %s", value.Type()))
+	}
+
+	return nil
+}
+
+// ignores reports whether segments lead to an unknown struct field of t,
+// such keys are skipped before any pointer, map entry or slice element
+// on the way is allocated, so they leave the target unchanged
+func (d *Decoder) ignores(t reflect.Type, segments []string) bool {
+	if d.disallowUnknownKeys {
+		return false
+	}
+
+	for _, segment := range segments {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := cachedTypeFields(t).lookup(segment)
+			if !ok {
+				return true
+			}
+
+			t = t.FieldByIndex(field.index).Type
+		case reflect.Map, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			// interfaces and invalid keys are resolved by decode
+			return false
+		}
+	}
+
+	return false
+}
+
+func (d *Decoder) decodeValue(e *entry, value reflect.Value) error {
+	if value.Kind() != reflect.Pointer && value.CanAddr() {
+		if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := unmarshaler.UnmarshalText([]byte(e.value)); err != nil {
+				return d.error(e, value.Type(), err)
+			}
+
+			return nil
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+
+		return d.decodeValue(e, value.Elem())
+	case reflect.Interface:
+		if !value.IsNil() && value.Elem().Kind() == reflect.Pointer {
+			return d.decodeValue(e, value.Elem())
+		}
+
+		if value.NumMethod() != 0 {
+			return d.error(e, value.Type(), errors.New("can't decode into non-empty interface"))
+		}
+
+		value.Set(reflect.ValueOf(e.value))
+	default:
+		if err := setScalar(value, e.value); err != nil {
+			return d.error(e, value.Type(), err)
+		}
+	}
+
+	return nil
+}
+
+func growSlice(value reflect.Value, length int) {
+	if length <= value.Cap() {
+		previous := value.Len()
+		value.SetLen(length)
+
+		// the tail may hold stale elements from earlier appends
+		for index := previous; index < length; index++ {
+			value.Index(index).SetZero()
+		}
+
+		return
+	}
+
+	grown := reflect.MakeSlice(value.Type(), length, max(length, 2*value.Cap()))
+	reflect.Copy(grown, value)
+	value.Set(grown)
+}
+
+func parseIndex(segment string) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid index '%s'", segment)
+	}
+
+	return index, nil
+}
+
+func parseMapKey(name string, keyType reflect.Type) (reflect.Value, error) {
+	if keyType.Kind() == reflect.String {
+		return reflect.ValueOf(name).Convert(keyType), nil
+	}
+
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		key := reflect.New(keyType)
+		if err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(name)); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return key.Elem(), nil
+	}
+
+	key := reflect.New(keyType).Elem()
+	switch keyType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if err := setScalar(key, name); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return key, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unsupported map key type %s", keyType)
+}
+
+func setScalar(value reflect.Value, text string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return parseError(text, value.Type())
+		}
+
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(text, 10, value.Type().Bits())
+		if err != nil {
+			return parseError(text, value.Type())
+		}
+
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		parsed, err := strconv.ParseUint(text, 10, value.Type().Bits())
+		if err != nil {
+			return parseError(text, value.Type())
+		}
+
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(text, value.Type().Bits())
+		if err != nil {
+			return parseError(text, value.Type())
+		}
+
+		value.SetFloat(parsed)
+	default:
+		return parseError(text, value.Type())
+	}
+
+	return nil
+}
+
+func parseError(text string, t reflect.Type) error {
+	return fmt.Errorf("cannot parse '%s' as %s", text, t)
+}
@@ -0,0 +1,398 @@
+package properties
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+var encoderCache sync.Map // map[reflect.Type]encoderFunc
+
+var statePool = sync.Pool{
+	New: func() any {
+		return &encodeState{}
+	},
+}
+
+type encoderFunc func(state *encodeState, value reflect.Value) error
+
+type encodeState struct {
+	buffer []byte
+	key    []byte
+	root   bool
+}
+
+func Marshal(v any) ([]byte, error) {
+	state := statePool.Get().(*encodeState)
+	defer statePool.Put(state)
+
+	if err := state.marshal(v); err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), state.buffer...), nil
+}
+
+type Encoder struct {
+	writer io.Writer
+	state  encodeState
+}
+
+func NewEncoder(writer io.Writer) *Encoder {
+	return &Encoder{writer: writer}
+}
+
+// Encode reuses internal buffers between calls, so encoding
+// a pointer to a struct of scalars doesn't allocate at all
+func (e *Encoder) Encode(v any) error {
+	if err := e.state.marshal(v); err != nil {
+		return err
+	}
+
+	_, err := e.writer.Write(e.state.buffer)
+	return err
+}
+
+func (s *encodeState) marshal(v any) error {
+	s.buffer = s.buffer[:0]
+	s.key = s.key[:0]
+	s.root = true
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return errors.New("properties: can't marshal nil value")
+		}
+
+		value = value.Elem()
+	}
+
+	if !value.IsValid() {
+		return errors.New("properties: can't marshal nil value")
+	}
+
+	kind := value.Kind()
+	if kind != reflect.Struct && kind != reflect.Map || value.Type().Implements(textMarshalerType) {
+		return fmt.Errorf("properties: can't marshal %s, only structs and maps are supported", value.Type())
+	}
+
+	if err := typeEncoder(value.Type())(s, value); err != nil {
+		return fmt.Errorf("properties: %w", err)
+	}
+
+	return nil
+}
+
+// prefix starts a nested key and returns its length,
+// keys of the top-level value don't have a leading dot
+func (s *encodeState) prefix() int {
+	if s.root {
+		s.root = false
+	} else {
+		s.key = append(s.key, '.')
+	}
+
+	return len(s.key)
+}
+
+func (s *encodeState) startLine() {
+	s.buffer = append(s.buffer, s.key...)
+	s.buffer = append(s.buffer, '=')
+}
+
+func (s *encodeState) endLine() {
+	s.buffer = append(s.buffer, '\n')
+}
+
+func typeEncoder(t reflect.Type) encoderFunc {
+	if encoder, ok := encoderCache.Load(t); ok {
+		return encoder.(encoderFunc)
+	}
+
+	// recursive types get the indirect encoder while their own one is compiled
+	var wg sync.WaitGroup
+	var compiled encoderFunc
+	wg.Add(1)
+
+	indirect := encoderFunc(func(state *encodeState, value reflect.Value) error {
+		wg.Wait()
+		return compiled(state, value)
+	})
+
+	if encoder, loaded := encoderCache.LoadOrStore(t, indirect); loaded {
+		return encoder.(encoderFunc)
+	}
+
+	compiled = newTypeEncoder(t)
+	wg.Done()
+
+	encoderCache.Store(t, compiled)
+	return compiled
+}
+
+func newTypeEncoder(t reflect.Type) encoderFunc {
+	if t.Kind() != reflect.Interface && t.Implements(textMarshalerType) {
+		return textMarshalerEncoder
+	}
+
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textMarshalerType) {
+		return addrTextMarshalerEncoder
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return newPointerEncoder(t)
+	case reflect.Interface:
+		return interfaceEncoder
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Map:
+		return newMapEncoder(t)
+	case reflect.Slice, reflect.Array:
+		return newSliceEncoder(t)
+	case reflect.String:
+		return stringEncoder
+	case reflect.Bool:
+		return boolEncoder
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intEncoder
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintEncoder
+	case reflect.Float32, reflect.Float64:
+		return floatEncoder
+	default:
+		return func(state *encodeState, value reflect.Value) error {
+			return fmt.Errorf("%s: unsupported type %s", state.key, t)
+		}
+	}
+}
+
+func textMarshalerEncoder(state *encodeState, value reflect.Value) error {
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil
+	}
+
+	return writeText(state, value.Interface().(encoding.TextMarshaler))
+}
+
+func addrTextMarshalerEncoder(state *encodeState, value reflect.Value) error {
+	if !value.CanAddr() {
+		// map values and values passed by copy aren't addressable
+		addressable := reflect.New(value.Type()).Elem()
+		addressable.Set(value)
+		value = addressable
+	}
+
+	return writeText(state, value.Addr().Interface().(encoding.TextMarshaler))
+}
+
+func writeText(state *encodeState, marshaler encoding.TextMarshaler) error {
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return fmt.Errorf("%s: %w", state.key, err)
+	}
+
+	state.startLine()
+	state.buffer = appendEscaped(state.buffer, string(text), false)
+	state.endLine()
+	return nil
+}
+
+func newPointerEncoder(t reflect.Type) encoderFunc {
+	elemEncoder := typeEncoder(t.Elem())
+	return func(state *encodeState, value reflect.Value) error {
+		if value.IsNil() {
+			return nil
+		}
+
+		return elemEncoder(state, value.Elem())
+	}
+}
+
+func interfaceEncoder(state *encodeState, value reflect.Value) error {
+	if value.IsNil() {
+		return nil
+	}
+
+	elem := value.Elem()
+	return typeEncoder(elem.Type())(state, elem)
+}
+
+type fieldEncoder struct {
+	key       []byte
+	index     []int
+	omitEmpty bool
+	encode    encoderFunc
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	fields := cachedTypeFields(t).list
+	encoders := make([]fieldEncoder, len(fields))
+
+	for i, field := range fields {
+		encoders[i] = fieldEncoder{
+			key:       appendEscaped(nil, field.name, true),
+			index:     field.index,
+			omitEmpty: field.omitEmpty,
+			encode:    typeEncoder(t.FieldByIndex(field.index).Type),
+		}
+	}
+
+	return func(state *encodeState, value reflect.Value) error {
+		mark := state.prefix()
+
+		for i := range encoders {
+			field := &encoders[i]
+			fieldValue := fieldByIndex(value, field.index)
+			if field.omitEmpty && fieldValue.IsZero() {
+				continue
+			}
+
+			state.key = append(state.key[:mark], field.key...)
+			if err := field.encode(state, fieldValue); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	if len(index) == 1 {
+		return value.Field(index[0])
+	}
+
+	return value.FieldByIndex(index)
+}
+
+func newMapEncoder(t reflect.Type) encoderFunc {
+	formatKey := newMapKeyFormatter(t.Key())
+	elemEncoder := typeEncoder(t.Elem())
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	return func(state *encodeState, value reflect.Value) error {
+		if formatKey == nil {
+			if len(state.key) == 0 {
+				return fmt.Errorf("unsupported map key type %s", t.Key())
+			}
+
+			return fmt.Errorf("%s: unsupported map key type %s", state.key, t.Key())
+		}
+
+		entries := make([]entry, 0, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			key, err := formatKey(iterator.Key())
+			if err != nil {
+				return fmt.Errorf("%s: %w", state.key, err)
+			}
+
+			entries = append(entries, entry{key: key, value: iterator.Value()})
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+
+		mark := state.prefix()
+		for _, entry := range entries {
+			state.key = appendEscaped(state.key[:mark], entry.key, true)
+			if err := elemEncoder(state, entry.value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func newMapKeyFormatter(t reflect.Type) func(reflect.Value) (string, error) {
+	if t.Kind() == reflect.String {
+		return func(key reflect.Value) (string, error) {
+			return key.String(), nil
+		}
+	}
+
+	if t.Implements(textMarshalerType) {
+		return func(key reflect.Value) (string, error) {
+			text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(key reflect.Value) (string, error) {
+			return strconv.FormatInt(key.Int(), 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(key reflect.Value) (string, error) {
+			return strconv.FormatUint(key.Uint(), 10), nil
+		}
+	}
+
+	return nil
+}
+
+func newSliceEncoder(t reflect.Type) encoderFunc {
+	elemEncoder := typeEncoder(t.Elem())
+	return func(state *encodeState, value reflect.Value) error {
+		mark := state.prefix()
+
+		for index := 0; index < value.Len(); index++ {
+			state.key = strconv.AppendInt(state.key[:mark], int64(index), 10)
+			if err := elemEncoder(state, value.Index(index)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func stringEncoder(state *encodeState, value reflect.Value) error {
+	state.startLine()
+	state.buffer = appendEscaped(state.buffer, value.String(), false)
+	state.endLine()
+	return nil
+}
+
+func boolEncoder(state *encodeState, value reflect.Value) error {
+	state.startLine()
+	state.buffer = strconv.AppendBool(state.buffer, value.Bool())
+	state.endLine()
+	return nil
+}
+
+func intEncoder(state *encodeState, value reflect.Value) error {
+	state.startLine()
+	state.buffer = strconv.AppendInt(state.buffer, value.Int(), 10)
+	state.endLine()
+	return nil
+}
+
+func uintEncoder(state *encodeState, value reflect.Value) error {
+	state.startLine()
+	state.buffer = strconv.AppendUint(state.buffer, value.Uint(), 10)
+	state.endLine()
+	return nil
+}
+
+func floatEncoder(state *encodeState, value reflect.Value) error {
+	state.startLine()
+	state.buffer = strconv.AppendFloat(state.buffer, value.Float(), 'g', -1, value.Type().Bits())
+	state.endLine()
+	return nil
+}
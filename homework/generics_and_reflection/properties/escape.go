@@ -0,0 +1,179 @@
+package properties
+
+import (
+	"errors"
+	"strings"
+)
+
+func appendEscaped(buffer []byte, value string, isKey bool) []byte {
+	if !needsEscaping(value, isKey) {
+		return append(buffer, value...)
+	}
+
+	for index := 0; index < len(value); index++ {
+		c := value[index]
+		switch c {
+		case '\\':
+			buffer = append(buffer, '\\', '\\')
+		case '\n':
+			buffer = append(buffer, '\\', 'n')
+		case '\r':
+			buffer = append(buffer, '\\', 'r')
+		case '\t':
+			buffer = append(buffer, '\\', 't')
+		case '=':
+			buffer = append(buffer, '\\', '=')
+		case '.':
+			if isKey {
+				buffer = append(buffer, '\\')
+			}
+			buffer = append(buffer, c)
+		case ' ':
+			// leading spaces are trimmed by the parser, keys can't contain them at all
+			if isKey || index == 0 {
+				buffer = append(buffer, '\\')
+			}
+			buffer = append(buffer, c)
+		case '#', '!':
+			if isKey && index == 0 {
+				buffer = append(buffer, '\\')
+			}
+			buffer = append(buffer, c)
+		default:
+			buffer = append(buffer, c)
+		}
+	}
+
+	return buffer
+}
+
+func needsEscaping(value string, isKey bool) bool {
+	for index := 0; index < len(value); index++ {
+		switch value[index] {
+		case '\\', '\n', '\r', '\t', '=':
+			return true
+		case '.':
+			if isKey {
+				return true
+			}
+		case ' ':
+			if isKey || index == 0 {
+				return true
+			}
+		case '#', '!':
+			if isKey && index == 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func unescape(value string) (string, error) {
+	if strings.IndexByte(value, '\\') < 0 {
+		return value, nil
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(value))
+
+	for index := 0; index < len(value); index++ {
+		c := value[index]
+		if c != '\\' {
+			builder.WriteByte(c)
+			continue
+		}
+
+		index++
+		if index == len(value) {
+			return "", errors.New("unterminated escape sequence")
+		}
+
+		switch value[index] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		default:
+			builder.WriteByte(value[index])
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// splitLine splits a line at the first unescaped separator
+func splitLine(line string) (key, value string, ok bool) {
+	for index := 0; index < len(line); index++ {
+		switch line[index] {
+		case '\\':
+			index++
+		case '=':
+			return trimSpace(line[:index]), trimLeftSpace(line[index+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// splitKey splits a raw key into unescaped path segments at every unescaped dot
+func splitKey(key string) ([]string, error) {
+	var segments []string
+
+	start := 0
+	for index := 0; index < len(key); index++ {
+		switch key[index] {
+		case '\\':
+			index++
+		case '.':
+			segment, err := unescape(key[start:index])
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, segment)
+			start = index + 1
+		}
+	}
+
+	segment, err := unescape(key[start:])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(segments, segment), nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+func trimLeftSpace(value string) string {
+	for len(value) > 0 && isSpace(value[0]) {
+		value = value[1:]
+	}
+
+	return value
+}
+
+// trimSpace keeps escaped trailing whitespace, so "key\ " stays "key\ "
+func trimSpace(value string) string {
+	value = trimLeftSpace(value)
+	for len(value) > 0 && isSpace(value[len(value)-1]) {
+		backslashes := 0
+		for index := len(value) - 2; index >= 0 && value[index] == '\\'; index-- {
+			backslashes++
+		}
+
+		if backslashes%2 == 1 {
+			break
+		}
+
+		value = value[:len(value)-1]
+	}
+
+	return value
+}
@@ -0,0 +1,106 @@
+package properties
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const tagName = "properties"
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+type structFields struct {
+	list   []field
+	byName map[string]int
+}
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+func parseTag(tag string) (name string, omitEmpty bool) {
+	for _, part := range strings.Split(tag, ",") {
+		switch part = strings.TrimSpace(part); part {
+		case "omitempty":
+			omitEmpty = true
+		case "":
+		default:
+			if name == "" {
+				name = part
+			}
+		}
+	}
+
+	return name, omitEmpty
+}
+
+func typeFields(t reflect.Type) []field {
+	var fields []field
+
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag := structField.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+
+		name, omitEmpty := parseTag(tag)
+
+		// untagged embedded structs are flattened into the parent like in encoding/json
+		if structField.Anonymous && name == "" && structField.Type.Kind() == reflect.Struct {
+			for _, embedded := range typeFields(structField.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+
+			continue
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = structField.Name
+		}
+
+		fields = append(fields, field{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: omitEmpty,
+		})
+	}
+
+	return fields
+}
+
+func cachedTypeFields(t reflect.Type) *structFields {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.(*structFields)
+	}
+
+	list := typeFields(t)
+	fields := &structFields{
+		list:   list,
+		byName: make(map[string]int, len(list)),
+	}
+
+	for i, field := range list {
+		fields.byName[field.name] = i
+	}
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.(*structFields)
+}
+
+func (f *structFields) lookup(name string) (field, bool) {
+	index, ok := f.byName[name]
+	if !ok {
+		return field{}, false
+	}
+
+	return f.list[index], true
+}
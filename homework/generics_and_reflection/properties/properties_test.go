@@ -0,0 +1,463 @@
+package properties
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v
+
+type Address struct {
+	City   string `properties:"city"`
+	Street string `properties:"street,omitempty"`
+}
+
+type Audit struct {
+	CreatedBy string `properties:"created_by"`
+}
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[l]), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return assert.AnError
+	}
+
+	return nil
+}
+
+type Config struct {
+	Audit
+
+	Name     string            `properties:"name"`
+	Note     string            `properties:"note,omitempty"`
+	Age      int               `properties:"omitempty,age"`
+	Ratio    float64           `properties:"ratio"`
+	Enabled  bool              `properties:"enabled"`
+	Port     uint16            `properties:"port"`
+	Home     *Address          `properties:"home"`
+	Work     Address           `properties:"work"`
+	Tags     []string          `properties:"tags,omitempty"`
+	Limits   map[string]int    `properties:"limits,omitempty"`
+	Ports    map[int]string    `properties:"ports,omitempty"`
+	Created  time.Time         `properties:"created"`
+	IP       net.IP            `properties:"ip,omitempty"`
+	Level    Level             `properties:"level"`
+	Offices  []Address         `properties:"offices,omitempty"`
+	Labels   map[string]string `properties:"labels,omitempty"`
+	Ignored  string            `properties:"-"`
+	Untagged string
+	hidden   string
+}
+
+func TestMarshal(t *testing.T) {
+	config := Config{
+		Audit:   Audit{CreatedBy: "admin"},
+		Name:    "John Doe",
+		Age:     30,
+		Ratio:   0.5,
+		Enabled: true,
+		Port:    8080,
+		Home:    &Address{City: "Paris", Street: "Rue de Rivoli"},
+		Work:    Address{City: "Berlin"},
+		Tags:    []string{"a", "b"},
+		Limits:  map[string]int{"rps": 100, "conn": 10},
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   1,
+		Ignored: "ignored",
+		hidden:  "hidden",
+	}
+
+	data, err := Marshal(config)
+	require.NoError(t, err)
+
+	expected := "created_by=admin\n" +
+		"name=John Doe\n" +
+		"age=30\n" +
+		"ratio=0.5\n" +
+		"enabled=true\n" +
+		"port=8080\n" +
+		"home.city=Paris\n" +
+		"home.street=Rue de Rivoli\n" +
+		"work.city=Berlin\n" +
+		"tags.0=a\n" +
+		"tags.1=b\n" +
+		"limits.conn=10\n" +
+		"limits.rps=100\n" +
+		"created=2024-01-02T03:04:05Z\n" +
+		"level=high\n" +
+		"Untagged=\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestMarshalEscaping(t *testing.T) {
+	data, err := Marshal(map[string]string{
+		"a=b":  "c=d",
+		"x.y":  "line1\nline2",
+		"#key": "  padded",
+		`\`:    `\`,
+	})
+	require.NoError(t, err)
+
+	expected := "\\#key=\\  padded\n" +
+		"\\\\=\\\\\n" +
+		"a\\=b=c\\=d\n" +
+		"x\\.y=line1\\nline2\n"
+	assert.Equal(t, expected, string(data))
+
+	var decoded map[string]string
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]string{
+		"a=b":  "c=d",
+		"x.y":  "line1\nline2",
+		"#key": "  padded",
+		`\`:    `\`,
+	}, decoded)
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		config Config
+	}{
+		"empty config": {},
+		"full config": {
+			config: Config{
+				Audit:    Audit{CreatedBy: "root"},
+				Name:     "name with = and\nnewline",
+				Note:     " leading space",
+				Age:      -42,
+				Ratio:    1e-9,
+				Enabled:  true,
+				Port:     65535,
+				Home:     &Address{City: "Paris"},
+				Work:     Address{City: "Berlin", Street: "Unter den Linden"},
+				Tags:     []string{"x", "", "z"},
+				Limits:   map[string]int{"dotted.key": 1, "other": 2},
+				Ports:    map[int]string{80: "http", 443: "https"},
+				Created:  time.Date(2020, 5, 6, 7, 8, 9, 10, time.UTC),
+				IP:       net.ParseIP("10.0.0.1"),
+				Level:    1,
+				Offices:  []Address{{City: "Rome"}, {City: "Oslo", Street: "Main"}},
+				Labels:   map[string]string{"": "empty key", "#": "hash"},
+				Untagged: "untagged",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := Marshal(&test.config)
+			require.NoError(t, err)
+
+			var decoded Config
+			require.NoError(t, Unmarshal(data, &decoded))
+			assert.Equal(t, test.config, decoded)
+		})
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buffer bytes.Buffer
+	encoder := NewEncoder(&buffer)
+
+	require.NoError(t, encoder.Encode(&Address{City: "Paris"}))
+	require.NoError(t, encoder.Encode(map[string]int{"b": 2, "a": 1}))
+	assert.Equal(t, "city=Paris\na=1\nb=2\n", buffer.String())
+}
+
+func TestEncoderDoesNotAllocate(t *testing.T) {
+	type Record struct {
+		ID      int64   `properties:"id"`
+		Name    string  `properties:"name"`
+		Score   float64 `properties:"score"`
+		Active  bool    `properties:"active"`
+		Address Address `properties:"address"`
+		Tags    []string
+	}
+
+	record := &Record{ID: 1, Name: "John", Score: 0.5, Active: true, Address: Address{City: "Paris"}, Tags: []string{"a"}}
+	encoder := NewEncoder(io.Discard)
+	require.NoError(t, encoder.Encode(record))
+
+	allocations := testing.AllocsPerRun(100, func() {
+		_ = encoder.Encode(record)
+	})
+	assert.Zero(t, allocations)
+}
+
+func TestRecursiveType(t *testing.T) {
+	type Node struct {
+		Value int   `properties:"value"`
+		Next  *Node `properties:"next"`
+	}
+
+	list := Node{Value: 1, Next: &Node{Value: 2, Next: &Node{Value: 3}}}
+	data, err := Marshal(list)
+	require.NoError(t, err)
+	assert.Equal(t, "value=1\nnext.value=2\nnext.next.value=3\n", string(data))
+
+	var decoded Node
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Equal(t, list, decoded)
+}
+
+func TestOmitEmptyWithUncomparableFields(t *testing.T) {
+	type Data struct {
+		Values []int          `properties:"values,omitempty"`
+		Index  map[string]int `properties:"index,omitempty"`
+		Func   func()         `properties:"func,omitempty"`
+	}
+
+	data, err := Marshal(Data{})
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	data, err = Marshal(Data{Values: []int{1}})
+	require.NoError(t, err)
+	assert.Equal(t, "values.0=1\n", string(data))
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := "# comment\n" +
+		"! another comment\n" +
+		"\n" +
+		"  name =  John\n" +
+		"age=30\n" +
+		"tags.1=second\n" +
+		"tags.0=first\n" +
+		"home.city=Paris\n" +
+		"unknown=value\n"
+
+	var config Config
+	require.NoError(t, Unmarshal([]byte(data), &config))
+	assert.Equal(t, Config{
+		Name: "John",
+		Age:  30,
+		Tags: []string{"first", "second"},
+		Home: &Address{City: "Paris"},
+	}, config)
+}
+
+func TestUnmarshalIntoAny(t *testing.T) {
+	var decoded map[string]any
+	require.NoError(t, Unmarshal([]byte("a=1\nb.c=2\n"), &decoded))
+	assert.Equal(t, map[string]any{
+		"a": "1",
+		"b": map[string]any{"c": "2"},
+	}, decoded)
+}
+
+func TestDecoder(t *testing.T) {
+	input := "# server settings\n" +
+		"name = very \\\n" +
+		"       long \\\n" +
+		"       name\n" +
+		"\n" +
+		"   ! indented comment \\\n" +
+		"tags.0 = first\n" +
+		"home.city=Paris\\\n" +
+		"  \\ \n" +
+		"path=C:\\\\\\\\\n" +
+		"age=30\r\n"
+
+	var config Config
+	require.NoError(t, NewDecoder(strings.NewReader(input)).Decode(&config))
+	assert.Equal(t, Config{
+		Name: "very long name",
+		Age:  30,
+		Tags: []string{"first"},
+		Home: &Address{City: "Paris "},
+	}, config)
+}
+
+func TestDecoderErrorPosition(t *testing.T) {
+	input := "# comment\n" +
+		"name=John\n" +
+		"tags.0=a\n" +
+		"\n" +
+		"age = \\\n" +
+		"  abc\n"
+
+	var config Config
+	err := NewDecoder(strings.NewReader(input)).Decode(&config)
+
+	var decodeError *DecodeError
+	require.True(t, errors.As(err, &decodeError))
+	assert.Equal(t, 6, decodeError.Line)
+	assert.Equal(t, 3, decodeError.Column)
+	assert.Equal(t, "age", decodeError.Key)
+	assert.Equal(t, reflect.TypeFor[int](), decodeError.Type)
+	assert.EqualError(t, err, "properties: line 6: age: cannot parse 'abc' as int")
+
+	err = NewDecoder(strings.NewReader("name=John\nnote=\\\n  a\nhome.city \\\n  Paris\n")).Decode(&config)
+
+	var syntaxError *SyntaxError
+	require.True(t, errors.As(err, &syntaxError))
+	assert.Equal(t, 5, syntaxError.Line)
+	assert.Equal(t, 8, syntaxError.Column)
+}
+
+func TestDecoderUnknownKeys(t *testing.T) {
+	input := "name=John\nnickname=Johnny\nhome.zip=75001\n"
+
+	var config Config
+	require.NoError(t, NewDecoder(strings.NewReader(input)).Decode(&config))
+	assert.Equal(t, Config{Name: "John"}, config)
+
+	// ignored keys don't add map entries or slice elements either
+	var nested struct {
+		Homes     map[string]*Address `properties:"homes"`
+		Addresses []Address           `properties:"addresses"`
+	}
+
+	require.NoError(t, Unmarshal([]byte("homes.work.zip=1\naddresses.3.zip=2\n"), &nested))
+	assert.Nil(t, nested.Homes)
+	assert.Nil(t, nested.Addresses)
+
+	config = Config{}
+	err := NewDecoder(strings.NewReader(input), DisallowUnknownKeys()).Decode(&config)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+	assert.EqualError(t, err, "properties: line 2: nickname: unknown key")
+
+	err = Unmarshal([]byte("home.zip=75001"), &config, DisallowUnknownKeys())
+	assert.EqualError(t, err, "properties: line 1: home.zip: unknown key")
+}
+
+func TestDecoderSliceIndexLimit(t *testing.T) {
+	var config Config
+	require.NoError(t, Unmarshal([]byte("tags."+strconv.Itoa(MaxSliceIndex)+"=last"), &config))
+	assert.Len(t, config.Tags, MaxSliceIndex+1)
+
+	for _, index := range []string{"65536", "100000000", "99999999999999"} {
+		config = Config{}
+		err := Unmarshal([]byte("tags."+index+"=x"), &config)
+
+		var decodeError *DecodeError
+		require.True(t, errors.As(err, &decodeError), index)
+		assert.Equal(t, "tags."+index, decodeError.Key)
+		assert.Nil(t, config.Tags)
+	}
+}
+
+func TestDecoderMergesSlices(t *testing.T) {
+	config := Config{Tags: make([]string, 1, 4)[:1]}
+	config.Tags[0] = "kept"
+
+	require.NoError(t, Unmarshal([]byte("tags.2=third"), &config))
+	assert.Equal(t, []string{"kept", "", "third"}, config.Tags)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data   string
+		target any
+		err    string
+	}{
+		"non pointer target": {
+			data:   "name=John",
+			target: Config{},
+			err:    "properties: can't unmarshal into non-pointer or nil value",
+		},
+		"missing separator": {
+			data:   "name=John\nage",
+			target: &Config{},
+			err:    "properties: line 2, column 4: missing '=' separator",
+		},
+		"invalid int": {
+			data:   "age=abc",
+			target: &Config{},
+			err:    "properties: line 1: age: cannot parse 'abc' as int",
+		},
+		"overflow": {
+			data:   "port=70000",
+			target: &Config{},
+			err:    "properties: line 1: port: cannot parse '70000' as uint16",
+		},
+		"invalid index": {
+			data:   "tags.x=a",
+			target: &Config{},
+			err:    "properties: line 1: tags.x: invalid index 'x'",
+		},
+		"text unmarshaler error": {
+			data:   "level=medium",
+			target: &Config{},
+			err:    "properties: line 1: level: " + assert.AnError.Error(),
+		},
+		"nested value for scalar": {
+			data:   "name.first=John",
+			target: &Config{},
+			err:    "properties: line 1: name.first: can't decode nested key into string",
+		},
+		"value for struct": {
+			data:   "work=Berlin",
+			target: &Config{},
+			err:    "properties: line 1: work: cannot parse 'Berlin' as properties.Address",
+		},
+		"continuation at end of input": {
+			data:   `name=John\`,
+			target: &Config{},
+			err:    "properties: line 1, column 10: unexpected end of input after line continuation",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Unmarshal([]byte(test.data), test.target)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		value any
+		err   string
+	}{
+		"nil": {
+			value: nil,
+			err:   "properties: can't marshal nil value",
+		},
+		"nil pointer": {
+			value: (*Config)(nil),
+			err:   "properties: can't marshal nil value",
+		},
+		"scalar": {
+			value: 42,
+			err:   "properties: can't marshal int, only structs and maps are supported",
+		},
+		"unsupported field": {
+			value: struct{ C chan int }{C: make(chan int)},
+			err:   "properties: C: unsupported type chan int",
+		},
+		"unsupported map key": {
+			value: map[float64]int{1.5: 1},
+			err:   "properties: unsupported map key type float64",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Marshal(test.value)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package safego
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+)
+
+type Kind uint8
+
+const (
+	KindValue           Kind = iota // panic with a value that isn't an error
+	KindError                       // panic with an error that isn't a runtime error
+	KindNilDereference              // nil pointer or nil func
+	KindIndexOutOfRange             // index or slice bounds
+	KindDivisionByZero              // integer division by zero
+	KindNilMap                      // assignment to entry in nil map
+	KindTypeAssertion               // failed type assertion
+	KindClosedChannel               // send on or close of closed channel
+	KindRuntime                     // other runtime errors
+)
+
+var kindNames = [...]string{
+	KindValue:           "panic",
+	KindError:           "error",
+	KindNilDereference:  "nil dereference",
+	KindIndexOutOfRange: "index out of range",
+	KindDivisionByZero:  "division by zero",
+	KindNilMap:          "nil map",
+	KindTypeAssertion:   "type assertion",
+	KindClosedChannel:   "closed channel",
+	KindRuntime:         "runtime error",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+
+	return "unknown"
+}
+
+// runtime errors don't have types for every case, so messages are
+// matched, they are stable since they are part of crash outputs
+var runtimeMessages = []struct {
+	substring string
+	kind      Kind
+}{
+	{substring: "nil pointer dereference", kind: KindNilDereference},
+	{substring: "index out of range", kind: KindIndexOutOfRange},
+	{substring: "slice bounds out of range", kind: KindIndexOutOfRange},
+	{substring: "integer divide by zero", kind: KindDivisionByZero},
+	{substring: "assignment to entry in nil map", kind: KindNilMap},
+	{substring: "closed channel", kind: KindClosedChannel},
+}
+
+// Classify returns the kind of a recovered value
+func Classify(value any) Kind {
+	err, ok := value.(error)
+	if !ok {
+		return KindValue
+	}
+
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		return KindError
+	}
+
+	var assertionErr *runtime.TypeAssertionError
+	if errors.As(err, &assertionErr) {
+		return KindTypeAssertion
+	}
+
+	message := runtimeErr.Error()
+	for _, runtimeMessage := range runtimeMessages {
+		if strings.Contains(message, runtimeMessage.substring) {
+			return runtimeMessage.kind
+		}
+	}
+
+	return KindRuntime
+}
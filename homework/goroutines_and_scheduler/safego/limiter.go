@@ -0,0 +1,73 @@
+package safego
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter reports a signature once per dedup window and not more than
+// limit reports per interval in total, suppressed crashes are counted
+// and the count goes with the next report of the same signature
+type limiter struct {
+	mutex      sync.Mutex
+	now        func() time.Time
+	window     time.Duration
+	limit      int
+	interval   time.Duration
+	started    time.Time
+	reported   int
+	sweptAt    time.Time
+	signatures map[string]*signatureState
+}
+
+type signatureState struct {
+	reportedAt time.Time
+	suppressed int
+}
+
+// allow returns the number of suppressed crashes with the signature or false
+func (l *limiter) allow(signature string) (int, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if now.Sub(l.sweptAt) >= l.window {
+		l.sweep(now)
+	}
+
+	state, ok := l.signatures[signature]
+	if !ok {
+		state = &signatureState{}
+		l.signatures[signature] = state
+	} else if now.Sub(state.reportedAt) < l.window {
+		state.suppressed++
+		return 0, false
+	}
+
+	if now.Sub(l.started) >= l.interval {
+		l.started, l.reported = now, 0
+	}
+
+	if l.limit > 0 && l.reported >= l.limit {
+		state.suppressed++
+		return 0, false
+	}
+
+	l.reported++
+	suppressed := state.suppressed
+	state.reportedAt, state.suppressed = now, 0
+	return suppressed, true
+}
+
+// sweep forgets signatures out of the dedup window without suppressed crashes,
+// they would be reported as new anyway, so a service producing new crash sites
+// doesn't grow the map forever, it runs at most once per window
+func (l *limiter) sweep(now time.Time) {
+	for signature, state := range l.signatures {
+		if state.suppressed == 0 && now.Sub(state.reportedAt) >= l.window {
+			delete(l.signatures, signature)
+		}
+	}
+
+	l.sweptAt = now
+}
@@ -0,0 +1,145 @@
+package safego
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxStackDepth = 64
+
+type Report struct {
+	Value     any
+	Kind      Kind
+	Stack     []byte // as printed by a crash
+	Signature string // hash of the kind and functions on the stack
+	Time      time.Time
+	// Suppressed is the number of crashes with the same
+	// signature dropped since the previous report
+	Suppressed int
+}
+
+type Option func(*Launcher)
+
+// WithDedup reports the same signature at most once per window
+func WithDedup(window time.Duration) Option {
+	return func(launcher *Launcher) {
+		launcher.limiter.window = window
+	}
+}
+
+// WithRateLimit reports at most limit crashes per interval
+func WithRateLimit(limit int, interval time.Duration) Option {
+	return func(launcher *Launcher) {
+		launcher.limiter.limit = limit
+		launcher.limiter.interval = interval
+	}
+}
+
+// Launcher runs goroutines that report panics to a sink instead of crashing the process
+type Launcher struct {
+	sink    Sink
+	limiter limiter
+	wg      sync.WaitGroup
+}
+
+func New(sink Sink, options ...Option) *Launcher {
+	launcher := &Launcher{
+		sink: sink,
+		limiter: limiter{
+			now:        time.Now,
+			window:     time.Minute,
+			limit:      100,
+			interval:   time.Minute,
+			signatures: make(map[string]*signatureState),
+		},
+	}
+
+	for _, option := range options {
+		option(launcher)
+	}
+
+	return launcher
+}
+
+var defaultLauncher = New(LogSink(slog.Default()))
+
+// Go runs action with the default launcher that logs reports with slog
+func Go(ctx context.Context, action func(ctx context.Context)) {
+	defaultLauncher.Go(ctx, action)
+}
+
+func (l *Launcher) Go(ctx context.Context, action func(ctx context.Context)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer l.recover()
+
+		action(ctx)
+	}()
+}
+
+// Wait waits for all goroutines started by the launcher
+func (l *Launcher) Wait() {
+	l.wg.Wait()
+}
+
+func (l *Launcher) recover() {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	// the panicking frames are still on the stack of the deferred call
+	kind := Classify(value)
+	signature := signatureOf(kind)
+
+	suppressed, ok := l.limiter.allow(signature)
+	if !ok {
+		return
+	}
+
+	l.sink.Report(Report{
+		Value:      value,
+		Kind:       kind,
+		Stack:      debug.Stack(),
+		Signature:  signature,
+		Time:       l.limiter.now(),
+		Suppressed: suppressed,
+	})
+}
+
+// signatureOf hashes functions and lines of the panicking goroutine, runtime
+// frames and frames of the launcher are skipped, so the signature doesn't
+// depend on how the runtime raised the panic
+func signatureOf(kind Kind) string {
+	var pcs [maxStackDepth]uintptr
+	count := runtime.Callers(3, pcs[:])
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(kind.String()))
+
+	frames := runtime.CallersFrames(pcs[:count])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && !strings.HasPrefix(frame.Function, launcherPrefix) {
+			_, _ = hash.Write([]byte(frame.Function + ":" + strconv.Itoa(frame.Line) + "\n"))
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+var launcherPrefix = reflect.TypeFor[Launcher]().PkgPath() + ".(*Launcher)."
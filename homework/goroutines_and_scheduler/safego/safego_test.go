@@ -0,0 +1,238 @@
+package safego
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+type collector struct {
+	mutex   sync.Mutex
+	reports []Report
+}
+
+func (c *collector) Report(report Report) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reports = append(c.reports, report)
+}
+
+func TestClassify(t *testing.T) {
+	errCustom := errors.New("custom")
+
+	tests := map[string]struct {
+		action func()
+		kind   Kind
+	}{
+		"value": {
+			action: func() { panic("value") },
+			kind:   KindValue,
+		},
+		"error": {
+			action: func() { panic(errCustom) },
+			kind:   KindError,
+		},
+		"nil dereference": {
+			action: func() {
+				var pointer *int
+				*pointer = 1
+			},
+			kind: KindNilDereference,
+		},
+		"index out of range": {
+			action: func() {
+				var data []int
+				index := 5
+				data[index] = 1
+			},
+			kind: KindIndexOutOfRange,
+		},
+		"slice bounds out of range": {
+			action: func() {
+				data := []int{1}
+				end := 5
+				_ = data[:end]
+			},
+			kind: KindIndexOutOfRange,
+		},
+		"division by zero": {
+			action: func() {
+				zero := 0
+				_ = 1 / zero
+			},
+			kind: KindDivisionByZero,
+		},
+		"nil map": {
+			action: func() {
+				var data map[string]int
+				data["key"] = 1
+			},
+			kind: KindNilMap,
+		},
+		"type assertion": {
+			action: func() {
+				var value any = "text"
+				_ = value.(int)
+			},
+			kind: KindTypeAssertion,
+		},
+		"closed channel": {
+			action: func() {
+				channel := make(chan int)
+				close(channel)
+				close(channel)
+			},
+			kind: KindClosedChannel,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sink := &collector{}
+			launcher := New(sink)
+			launcher.Go(context.Background(), func(context.Context) {
+				test.action()
+			})
+
+			launcher.Wait()
+			require.Len(t, sink.reports, 1)
+
+			report := sink.reports[0]
+			assert.Equal(t, test.kind, report.Kind)
+			assert.Equal(t, test.kind, Classify(report.Value))
+			assert.Contains(t, string(report.Stack), "safego_test.go")
+			assert.Len(t, report.Signature, 16)
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	now := time.Now()
+	sink := &collector{}
+	launcher := New(sink, WithDedup(time.Minute))
+	launcher.limiter.now = func() time.Time { return now }
+
+	crash := func(context.Context) {
+		var data map[int]int
+		data[1] = 1
+	}
+
+	for range 5 {
+		launcher.Go(context.Background(), crash)
+		launcher.Wait()
+	}
+
+	// another call site has another signature
+	launcher.Go(context.Background(), func(context.Context) {
+		panic("other")
+	})
+	launcher.Wait()
+
+	require.Len(t, sink.reports, 2)
+	assert.NotEqual(t, sink.reports[0].Signature, sink.reports[1].Signature)
+
+	now = now.Add(time.Minute)
+	launcher.Go(context.Background(), crash)
+	launcher.Wait()
+
+	require.Len(t, sink.reports, 3)
+	assert.Equal(t, sink.reports[0].Signature, sink.reports[2].Signature)
+	assert.Equal(t, 4, sink.reports[2].Suppressed)
+	assert.Equal(t, now, sink.reports[2].Time)
+}
+
+func TestDedupForgetsOldSignatures(t *testing.T) {
+	now := time.Now()
+	sink := &collector{}
+	launcher := New(sink, WithDedup(time.Minute))
+	launcher.limiter.now = func() time.Time { return now }
+
+	launcher.limiter.signatures["reported"] = &signatureState{reportedAt: now}
+	launcher.limiter.signatures["suppressed"] = &signatureState{reportedAt: now, suppressed: 1}
+
+	now = now.Add(time.Minute)
+	launcher.Go(context.Background(), func(context.Context) {
+		panic("new")
+	})
+	launcher.Wait()
+
+	// signatures with suppressed crashes are kept until their next report
+	require.Len(t, sink.reports, 1)
+	assert.Len(t, launcher.limiter.signatures, 2)
+	assert.NotContains(t, launcher.limiter.signatures, "reported")
+	assert.Contains(t, launcher.limiter.signatures, "suppressed")
+	assert.Contains(t, launcher.limiter.signatures, sink.reports[0].Signature)
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Now()
+	sink := &collector{}
+	launcher := New(sink, WithDedup(0), WithRateLimit(2, time.Second))
+	launcher.limiter.now = func() time.Time { return now }
+
+	crash := func(context.Context) {
+		panic("crash")
+	}
+
+	for range 5 {
+		launcher.Go(context.Background(), crash)
+	}
+
+	launcher.Wait()
+	assert.Len(t, sink.reports, 2)
+
+	now = now.Add(time.Second)
+	launcher.Go(context.Background(), crash)
+	launcher.Wait()
+
+	require.Len(t, sink.reports, 3)
+	assert.Equal(t, 3, sink.reports[2].Suppressed)
+}
+
+func TestWithoutPanic(t *testing.T) {
+	sink := &collector{}
+	launcher := New(sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	launcher.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		close(done)
+	})
+
+	cancel()
+	launcher.Wait()
+	<-done
+	assert.Empty(t, sink.reports)
+}
+
+func TestLogSink(t *testing.T) {
+	var buffer bytes.Buffer
+	launcher := New(LogSink(slog.New(slog.NewTextHandler(&buffer, nil))))
+	launcher.Go(context.Background(), func(context.Context) {
+		panic("log me")
+	})
+
+	launcher.Wait()
+	assert.Contains(t, buffer.String(), "goroutine panicked")
+	assert.Contains(t, buffer.String(), "panic=\"log me\"")
+	assert.Contains(t, buffer.String(), "kind=panic")
+
+	// the default launcher doesn't crash the process either
+	done := make(chan struct{})
+	Go(context.Background(), func(context.Context) {
+		defer close(done)
+		panic("default")
+	})
+	<-done
+}
@@ -0,0 +1,37 @@
+package safego
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Sink receives crash reports, it's called from crashed goroutines
+// concurrently, so implementations have to be safe for concurrent use
+type Sink interface {
+	Report(report Report)
+}
+
+type SinkFunc func(report Report)
+
+func (f SinkFunc) Report(report Report) {
+	f(report)
+}
+
+type logSink struct {
+	logger *slog.Logger
+}
+
+// LogSink writes reports as errors with the stack in a separate attribute
+func LogSink(logger *slog.Logger) Sink {
+	return logSink{logger: logger}
+}
+
+func (s logSink) Report(report Report) {
+	s.logger.LogAttrs(context.Background(), slog.LevelError, "goroutine panicked",
+		slog.Any("panic", report.Value),
+		slog.String("kind", report.Kind.String()),
+		slog.String("signature", report.Signature),
+		slog.Int("suppressed", report.Suppressed),
+		slog.String("stack", string(report.Stack)),
+	)
+}
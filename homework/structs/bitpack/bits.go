@@ -0,0 +1,35 @@
+package bitpack
+
+// getBits and setBits work with at most 64 bits starting at any bit of a record,
+// only bytes covered by the field are touched
+
+func getBits(record []byte, offset int, width int) uint64 {
+	var value uint64
+	for read := 0; read < width; {
+		index, shift := (offset+read)/8, (offset+read)%8
+		chunk := min(8-shift, width-read)
+
+		bits := uint64(record[index]>>shift) & (1<<chunk - 1)
+		value |= bits << read
+		read += chunk
+	}
+
+	return value
+}
+
+func setBits(record []byte, offset int, width int, value uint64) {
+	for written := 0; written < width; {
+		index, shift := (offset+written)/8, (offset+written)%8
+		chunk := min(8-shift, width-written)
+
+		mask := byte(1<<chunk-1) << shift
+		bits := byte(value>>written) << shift
+		record[index] = record[index]&^mask | bits&mask
+		written += chunk
+	}
+}
+
+func signExtend(value uint64, width int) int64 {
+	shift := 64 - width
+	return int64(value<<shift) >> shift
+}
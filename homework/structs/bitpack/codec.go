@@ -0,0 +1,327 @@
+package bitpack
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+const tagName = "bits"
+
+var (
+	ErrOverflow        = errors.New("value doesn't fit into its bit width")
+	ErrInvalidTag      = errors.New("invalid bits tag")
+	ErrUnsupportedType = errors.New("unsupported field type")
+	ErrShortBuffer     = errors.New("buffer is shorter than the record")
+	ErrUnknownField    = errors.New("unknown field")
+)
+
+type kind uint8
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindUint
+	kindString
+)
+
+// Field packs a single struct field, bit i of a record is bit i%8 of byte i/8
+type Field struct {
+	name   string
+	index  int
+	kind   kind
+	offset int
+	width  int
+}
+
+func (f *Field) Name() string {
+	return f.name
+}
+
+func (f *Field) Offset() int {
+	return f.offset
+}
+
+func (f *Field) Width() int {
+	return f.width
+}
+
+// Codec packs structs of type T into records of Size bytes, fields are laid out
+// in declaration order, so the layout is defined by the struct itself
+type Codec[T any] struct {
+	fields []Field
+	byName map[string]int
+	size   int
+}
+
+// NewCodec reads widths from `bits:"n"` tags, untagged integers and booleans take
+// their full width, strings need a tag with a multiple of 8 and fields tagged "-" are skipped
+func NewCodec[T any]() (*Codec[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s isn't a struct", ErrUnsupportedType, t)
+	}
+
+	codec := &Codec[T]{byName: make(map[string]int)}
+
+	offset := 0
+	for index := 0; index < t.NumField(); index++ {
+		structField := t.Field(index)
+		tag, tagged := structField.Tag.Lookup(tagName)
+		if tag == "-" {
+			continue
+		}
+
+		field, err := newField(structField, tag, tagged)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", structField.Name, err)
+		}
+
+		field.index = index
+		field.offset = offset
+		offset += field.width
+
+		codec.byName[field.name] = len(codec.fields)
+		codec.fields = append(codec.fields, field)
+	}
+
+	codec.size = (offset + 7) / 8
+	return codec, nil
+}
+
+func newField(structField reflect.StructField, tag string, tagged bool) (Field, error) {
+	field := Field{name: structField.Name}
+	var maxWidth int
+
+	switch structField.Type.Kind() {
+	case reflect.Bool:
+		field.kind = kindBool
+		maxWidth = 1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.kind = kindInt
+		maxWidth = structField.Type.Bits()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.kind = kindUint
+		maxWidth = structField.Type.Bits()
+	case reflect.String:
+		field.kind = kindString
+		if !tagged {
+			return Field{}, fmt.Errorf("%w: strings need a width", ErrInvalidTag)
+		}
+	default:
+		return Field{}, fmt.Errorf("%w: %s", ErrUnsupportedType, structField.Type)
+	}
+
+	if !structField.IsExported() {
+		return Field{}, fmt.Errorf("%w: unexported field", ErrUnsupportedType)
+	}
+
+	field.width = maxWidth
+	if tagged {
+		width, err := strconv.Atoi(tag)
+		if err != nil || width <= 0 || maxWidth > 0 && width > maxWidth || field.kind == kindString && width%8 != 0 {
+			return Field{}, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+
+		field.width = width
+	}
+
+	return field, nil
+}
+
+// Size returns the record size in bytes
+func (c *Codec[T]) Size() int {
+	return c.size
+}
+
+func (c *Codec[T]) Fields() []Field {
+	return c.fields
+}
+
+func (c *Codec[T]) Field(name string) (*Field, error) {
+	index, ok := c.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+	}
+
+	return &c.fields[index], nil
+}
+
+// Marshal returns a new record, see Pack
+func (c *Codec[T]) Marshal(value *T) ([]byte, error) {
+	record := make([]byte, c.size)
+	if err := c.Pack(value, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Pack validates all fields before writing, so the record
+// isn't changed when a value doesn't fit into its width
+func (c *Codec[T]) Pack(value *T, record []byte) error {
+	if len(record) < c.size {
+		return ErrShortBuffer
+	}
+
+	structValue := reflect.ValueOf(value).Elem()
+	for index := range c.fields {
+		if err := c.fields[index].check(structValue.Field(c.fields[index].index)); err != nil {
+			return err
+		}
+	}
+
+	for index := range c.fields {
+		c.fields[index].pack(structValue.Field(c.fields[index].index), record)
+	}
+
+	return nil
+}
+
+func (c *Codec[T]) Unpack(record []byte, value *T) error {
+	if len(record) < c.size {
+		return ErrShortBuffer
+	}
+
+	structValue := reflect.ValueOf(value).Elem()
+	for index := range c.fields {
+		c.fields[index].unpack(record, structValue.Field(c.fields[index].index))
+	}
+
+	return nil
+}
+
+func (f *Field) check(value reflect.Value) error {
+	switch f.kind {
+	case kindInt:
+		return f.checkInt(value.Int())
+	case kindUint:
+		return f.checkUint(value.Uint())
+	case kindString:
+		if len(value.String()) > f.width/8 {
+			return f.overflow(strconv.Quote(value.String()))
+		}
+	}
+
+	return nil
+}
+
+func (f *Field) pack(value reflect.Value, record []byte) {
+	switch f.kind {
+	case kindBool:
+		var bit uint64
+		if value.Bool() {
+			bit = 1
+		}
+
+		setBits(record, f.offset, f.width, bit)
+	case kindInt:
+		setBits(record, f.offset, f.width, uint64(value.Int()))
+	case kindUint:
+		setBits(record, f.offset, f.width, value.Uint())
+	case kindString:
+		text := value.String()
+		for index := 0; index < f.width/8; index++ {
+			var char uint64
+			if index < len(text) {
+				char = uint64(text[index])
+			}
+
+			setBits(record, f.offset+8*index, 8, char)
+		}
+	}
+}
+
+func (f *Field) unpack(record []byte, value reflect.Value) {
+	switch f.kind {
+	case kindBool:
+		value.SetBool(getBits(record, f.offset, f.width) != 0)
+	case kindInt:
+		value.SetInt(signExtend(getBits(record, f.offset, f.width), f.width))
+	case kindUint:
+		value.SetUint(getBits(record, f.offset, f.width))
+	case kindString:
+		value.SetString(f.unpackString(record))
+	}
+}
+
+func (f *Field) unpackString(record []byte) string {
+	text := make([]byte, 0, f.width/8)
+	for index := 0; index < f.width/8; index++ {
+		text = append(text, byte(getBits(record, f.offset+8*index, 8)))
+	}
+
+	// the tail of short strings is padded with zeros
+	for len(text) > 0 && text[len(text)-1] == 0 {
+		text = text[:len(text)-1]
+	}
+
+	return string(text)
+}
+
+func (f *Field) checkInt(value int64) error {
+	if f.width < 64 {
+		limit := int64(1) << (f.width - 1)
+		if value < -limit || value >= limit {
+			return f.overflow(strconv.FormatInt(value, 10))
+		}
+	}
+
+	return nil
+}
+
+func (f *Field) checkUint(value uint64) error {
+	if f.width < 64 && value>>f.width != 0 {
+		return f.overflow(strconv.FormatUint(value, 10))
+	}
+
+	return nil
+}
+
+func (f *Field) overflow(value string) error {
+	return fmt.Errorf("%w: %s = %s, width %d", ErrOverflow, f.name, value, f.width)
+}
+
+// Int reads a boolean or integer field straight from a record
+func (f *Field) Int(record []byte) int64 {
+	bits := getBits(record, f.offset, f.width)
+	if f.kind == kindInt {
+		return signExtend(bits, f.width)
+	}
+
+	return int64(bits)
+}
+
+// SetInt writes a boolean or integer field straight into a record
+func (f *Field) SetInt(record []byte, value int64) error {
+	var err error
+	switch f.kind {
+	case kindInt:
+		err = f.checkInt(value)
+	case kindUint:
+		if value < 0 {
+			err = f.overflow(strconv.FormatInt(value, 10))
+		} else {
+			err = f.checkUint(uint64(value))
+		}
+	case kindBool:
+		if value != 0 && value != 1 {
+			err = f.overflow(strconv.FormatInt(value, 10))
+		}
+	default:
+		return fmt.Errorf("%w: %s isn't an integer", ErrUnsupportedType, f.name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	setBits(record, f.offset, f.width, uint64(value))
+	return nil
+}
+
+// String reads a string field straight from a record
+func (f *Field) String(record []byte) string {
+	return f.unpackString(record)
+}
@@ -0,0 +1,231 @@
+package bitpack
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+// Person has the layout of GamePerson from the structs homework
+type Person struct {
+	Name       string `bits:"336"`
+	X          int32
+	Y          int32
+	Z          int32
+	Gold       int32
+	Mana       uint16 `bits:"10"`
+	Health     uint16 `bits:"10"`
+	Respect    uint8  `bits:"4"`
+	Strength   uint8  `bits:"4"`
+	Experience uint8  `bits:"4"`
+	Level      uint8
+	HasHouse   bool
+	HasGun     bool
+	HasFamily  bool
+	Type       uint8 `bits:"2"`
+	cached     []int `bits:"-"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec, err := NewCodec[Person]()
+	require.NoError(t, err)
+	assert.Equal(t, 64, codec.Size())
+
+	person := Person{
+		Name:       "aaaaaaaaaaaaa_bbbbbbbbbbbbb_cccccccccccccc",
+		X:          math.MinInt32,
+		Y:          math.MaxInt32 - 1,
+		Gold:       math.MaxInt32,
+		Mana:       999,
+		Health:     1000,
+		Respect:    7,
+		Strength:   8,
+		Experience: 15,
+		Level:      10,
+		HasHouse:   true,
+		HasFamily:  true,
+		Type:       2,
+	}
+
+	record, err := codec.Marshal(&person)
+	require.NoError(t, err)
+	assert.Len(t, record, 64)
+
+	var unpacked Person
+	require.NoError(t, codec.Unpack(record, &unpacked))
+	assert.Equal(t, person, unpacked)
+
+	short := Person{Name: "Ivan", Z: -1}
+	require.NoError(t, codec.Pack(&short, record))
+	require.NoError(t, codec.Unpack(record, &unpacked))
+	assert.Equal(t, short, unpacked)
+}
+
+func TestCodecOverflow(t *testing.T) {
+	codec, err := NewCodec[Person]()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		person Person
+		field  string
+	}{
+		"mana over 10 bits": {
+			person: Person{Mana: 1024},
+			field:  "Mana",
+		},
+		"respect over 4 bits": {
+			person: Person{Respect: 16},
+			field:  "Respect",
+		},
+		"type over 2 bits": {
+			person: Person{Type: 4},
+			field:  "Type",
+		},
+		"name over 42 bytes": {
+			person: Person{Name: "aaaaaaaaaaaaa_bbbbbbbbbbbbb_cccccccccccccc_d"},
+			field:  "Name",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			record := make([]byte, codec.Size())
+			record[0] = 0xFF
+
+			err := codec.Pack(&test.person, record)
+			assert.ErrorIs(t, err, ErrOverflow)
+			assert.ErrorContains(t, err, test.field)
+
+			// nothing is written when validation fails
+			assert.Equal(t, byte(0xFF), record[0])
+		})
+	}
+
+	_, err = codec.Marshal(&Person{Mana: 1023})
+	assert.NoError(t, err)
+
+	err = codec.Pack(&Person{}, make([]byte, 63))
+	assert.ErrorIs(t, err, ErrShortBuffer)
+}
+
+type Signed struct {
+	Small  int8  `bits:"3"`
+	Medium int16 `bits:"9"`
+	Full   int64
+	Flag   bool
+	Count  uint64 `bits:"64"`
+}
+
+func TestSignedFields(t *testing.T) {
+	codec, err := NewCodec[Signed]()
+	require.NoError(t, err)
+	assert.Equal(t, (3+9+64+1+64+7)/8, codec.Size())
+
+	value := Signed{Small: -4, Medium: 255, Full: math.MinInt64, Flag: true, Count: math.MaxUint64}
+	record, err := codec.Marshal(&value)
+	require.NoError(t, err)
+
+	var unpacked Signed
+	require.NoError(t, codec.Unpack(record, &unpacked))
+	assert.Equal(t, value, unpacked)
+
+	_, err = codec.Marshal(&Signed{Small: 4})
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = codec.Marshal(&Signed{Small: -5})
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = codec.Marshal(&Signed{Medium: -257})
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestFieldAccessors(t *testing.T) {
+	codec, err := NewCodec[Person]()
+	require.NoError(t, err)
+
+	record, err := codec.Marshal(&Person{Name: "Ivan", Health: 500, Respect: 3})
+	require.NoError(t, err)
+
+	health, err := codec.Field("Health")
+	require.NoError(t, err)
+	assert.Equal(t, 336+128+10, health.Offset())
+	assert.Equal(t, 10, health.Width())
+	assert.Equal(t, int64(500), health.Int(record))
+
+	require.NoError(t, health.SetInt(record, 1023))
+	assert.ErrorIs(t, health.SetInt(record, 1024), ErrOverflow)
+	assert.ErrorIs(t, health.SetInt(record, -1), ErrOverflow)
+
+	name, err := codec.Field("Name")
+	require.NoError(t, err)
+	assert.Equal(t, "Ivan", name.String(record))
+	assert.ErrorIs(t, name.SetInt(record, 1), ErrUnsupportedType)
+
+	house, err := codec.Field("HasHouse")
+	require.NoError(t, err)
+	require.NoError(t, house.SetInt(record, 1))
+	assert.ErrorIs(t, house.SetInt(record, 2), ErrOverflow)
+
+	var person Person
+	require.NoError(t, codec.Unpack(record, &person))
+	assert.Equal(t, Person{Name: "Ivan", Health: 1023, Respect: 3, HasHouse: true}, person)
+
+	_, err = codec.Field("cached")
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
+
+func TestInvalidSchemas(t *testing.T) {
+	type tooWide struct {
+		Value uint8 `bits:"9"`
+	}
+
+	type zeroWidth struct {
+		Value uint8 `bits:"0"`
+	}
+
+	type untaggedString struct {
+		Name string
+	}
+
+	type unalignedString struct {
+		Name string `bits:"12"`
+	}
+
+	type pointer struct {
+		Next *pointer
+	}
+
+	type unexported struct {
+		value int
+	}
+
+	_, err := NewCodec[tooWide]()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, err = NewCodec[zeroWidth]()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, err = NewCodec[untaggedString]()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, err = NewCodec[unalignedString]()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, err = NewCodec[pointer]()
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = NewCodec[unexported]()
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = NewCodec[int]()
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestBits(t *testing.T) {
+	record := make([]byte, 4)
+	setBits(record, 5, 13, 0x1FFF)
+	assert.Equal(t, []byte{0xE0, 0xFF, 0x03, 0x00}, record)
+	assert.Equal(t, uint64(0x1FFF), getBits(record, 5, 13))
+
+	setBits(record, 7, 3, 0b010)
+	assert.Equal(t, []byte{0x60, 0xFD, 0x03, 0x00}, record)
+	assert.Equal(t, uint64(0b010), getBits(record, 7, 3))
+	assert.Equal(t, int64(-1), signExtend(0b111, 3))
+	assert.Equal(t, int64(3), signExtend(0b011, 3))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// go test -bench=. -benchmem
+
+var Sink []int
+
+var benchmarkQuery = Query{
+	Type:    WarriorGamePersonType,
+	MinGold: 900,
+	Box:     Box{MinX: -100, MinY: -100, MinZ: -100, MaxX: 100, MaxY: 100, MaxZ: 100},
+}
+
+func generatePersons(b *testing.B, size int) []GamePerson {
+	r := rand.New(rand.NewSource(42))
+	persons := make([]GamePerson, size)
+	for i := range persons {
+		person, err := NewGamePerson(
+			WithCoordinates(r.Intn(1000)-500, r.Intn(1000)-500, r.Intn(1000)-500),
+			WithGold(r.Intn(1000)),
+			WithType(r.Intn(3)),
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		persons[i] = person
+	}
+
+	return persons
+}
+
+func BenchmarkSliceOfPersons(b *testing.B) {
+	persons := generatePersons(b, 1_000_000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var indices []int
+		for index := range persons {
+			person := &persons[index]
+			if person.Type() == benchmarkQuery.Type && person.Gold() > benchmarkQuery.MinGold &&
+				benchmarkQuery.Box.contains(person.x, person.y, person.z) {
+				indices = append(indices, index)
+			}
+		}
+
+		Sink = indices
+	}
+}
+
+func BenchmarkPersonStore(b *testing.B) {
+	persons := generatePersons(b, 1_000_000)
+	store := NewPersonStore(len(persons))
+	for _, person := range persons {
+		store.Add(person)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Sink = store.Select(benchmarkQuery)
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// GamePersonSize is the size of a person in the binary format
+const GamePersonSize = 64
+
+// NameLength is the maximum name length in bytes
+const NameLength = 42
+
+var (
+	ErrInvalidLength = errors.New("invalid data length")
+	ErrNameTooLong   = errors.New("name is too long")
+	ErrInvalidName   = errors.New("name isn't valid UTF-8 text")
+	ErrOutOfRange    = errors.New("value is out of range")
+)
+
+type Option func(*GamePerson) error
+
+const (
+	ManaStart        = 0
+	ManaLength       = 10
+	HealthStart      = ManaStart + ManaLength
+	HealthLength     = 10
+	RespectStart     = HealthStart + HealthLength
+	RespectLength    = 4
+	StrengthStart    = RespectStart + RespectLength
+	StrengthLength   = 4
+	ExperienceStart  = StrengthStart + StrengthLength
+	ExperienceLength = 4
+	HouseStart       = 0
+	HouseLength      = 1
+	GunStart         = HouseStart + HouseLength
+	GunLength        = 1
+	FamilyStart      = GunStart + GunLength
+	FamilyLength     = 1
+	TypeStart        = FamilyStart + FamilyLength
+	TypeLength       = 2
+)
+
+func WithName(name string) Option {
+	return func(person *GamePerson) error {
+		if len(name) > NameLength {
+			return fmt.Errorf("%w: %d bytes, expected at most %d", ErrNameTooLong, len(name), NameLength)
+		}
+
+		if !utf8.ValidString(name) || strings.IndexByte(name, 0) >= 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+
+		clear(person.nameLevelHouseGunFamilyType[0:NameLength])
+		copy(person.nameLevelHouseGunFamilyType[0:NameLength], name)
+		return nil
+	}
+}
+
+func WithCoordinates(x, y, z int) Option {
+	return func(person *GamePerson) error {
+		for _, coordinate := range []int{x, y, z} {
+			if err := checkRange("coordinate", coordinate, math.MinInt32, math.MaxInt32); err != nil {
+				return err
+			}
+		}
+
+		person.x = int32(x)
+		person.y = int32(y)
+		person.z = int32(z)
+		return nil
+	}
+}
+
+func WithGold(gold int) Option {
+	return func(person *GamePerson) error {
+		if err := checkRange("gold", gold, math.MinInt32, math.MaxInt32); err != nil {
+			return err
+		}
+
+		person.gold = int32(gold)
+		return nil
+	}
+}
+
+func WithMana(mana int) Option {
+	return withStat("mana", mana, ManaStart, ManaLength)
+}
+
+func WithHealth(health int) Option {
+	return withStat("health", health, HealthStart, HealthLength)
+}
+
+func WithRespect(respect int) Option {
+	return withStat("respect", respect, RespectStart, RespectLength)
+}
+
+func WithStrength(strength int) Option {
+	return withStat("strength", strength, StrengthStart, StrengthLength)
+}
+
+func WithExperience(experience int) Option {
+	return withStat("experience", experience, ExperienceStart, ExperienceLength)
+}
+
+func withStat(name string, value int, start int, length int) Option {
+	return func(person *GamePerson) error {
+		if err := checkRange(name, value, 0, 1<<length-1); err != nil {
+			return err
+		}
+
+		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(value), start, length)
+		return nil
+	}
+}
+
+func WithLevel(level int) Option {
+	return func(person *GamePerson) error {
+		if err := checkRange("level", level, 0, math.MaxUint8); err != nil {
+			return err
+		}
+
+		person.nameLevelHouseGunFamilyType[42] = uint8(level)
+		return nil
+	}
+}
+
+func WithHouse() Option {
+	return withFlag(HouseStart, HouseLength)
+}
+
+func WithGun() Option {
+	return withFlag(GunStart, GunLength)
+}
+
+func WithFamily() Option {
+	return withFlag(FamilyStart, FamilyLength)
+}
+
+func withFlag(start int, length int) Option {
+	return func(person *GamePerson) error {
+		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], 1, start, length)
+		return nil
+	}
+}
+
+func WithType(personType int) Option {
+	return func(person *GamePerson) error {
+		if err := checkRange("type", personType, BuilderGamePersonType, WarriorGamePersonType); err != nil {
+			return err
+		}
+
+		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], uint8(personType), TypeStart, TypeLength)
+		return nil
+	}
+}
+
+func checkRange(name string, value int, minValue int, maxValue int) error {
+	if value < minValue || value > maxValue {
+		return fmt.Errorf("%w: %s = %d, expected %d..%d", ErrOutOfRange, name, value, minValue, maxValue)
+	}
+
+	return nil
+}
+
+const (
+	BuilderGamePersonType = iota
+	BlacksmithGamePersonType
+	WarriorGamePersonType
+)
+
+type GamePerson struct {
+	x    int32
+	y    int32
+	z    int32
+	gold int32
+	// bits: 0..9 - mana 10..19 - health 20..23 - respect 24..27 - strength 28..31 - experience
+	manaHealthRespectStrengthExperience uint32
+	// bytes: 0..41 - name, 42 - level, 43: bits: 0 - house,1 - gun, 2 - family, 3..4 - type
+	nameLevelHouseGunFamilyType [44]uint8
+}
+
+func NewGamePerson(options ...Option) (GamePerson, error) {
+	person := GamePerson{}
+
+	for _, option := range options {
+		if err := option(&person); err != nil {
+			return GamePerson{}, err
+		}
+	}
+
+	return person, nil
+}
+
+// Name returns the name without zero padding, a rune cut by
+// the name length in binary data is dropped as well
+func (p *GamePerson) Name() string {
+	name := p.nameLevelHouseGunFamilyType[0:NameLength]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+
+	for len(name) > 0 {
+		if r, size := utf8.DecodeLastRune(name); r != utf8.RuneError || size != 1 {
+			break
+		}
+
+		name = name[:len(name)-1]
+	}
+
+	return string(name)
+}
+
+func (p *GamePerson) X() int {
+	return int(p.x)
+}
+
+func (p *GamePerson) Y() int {
+	return int(p.y)
+}
+
+func (p *GamePerson) Z() int {
+	return int(p.z)
+}
+
+func (p *GamePerson) Gold() int {
+	return int(p.gold)
+}
+
+func (p *GamePerson) Mana() int {
+	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 0, 10))
+}
+
+func (p *GamePerson) Health() int {
+	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 10, 10))
+}
+
+func (p *GamePerson) Respect() int {
+	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 20, 4))
+}
+
+func (p *GamePerson) Strength() int {
+	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 24, 4))
+}
+
+func (p *GamePerson) Experience() int {
+	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 28, 4))
+}
+
+func (p *GamePerson) Level() int {
+	return int(p.nameLevelHouseGunFamilyType[42])
+}
+
+func (p *GamePerson) HasHouse() bool {
+	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 0, 1) > 0
+}
+
+func (p *GamePerson) HasGun() bool {
+	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 1, 1) > 0
+}
+
+func (p *GamePerson) HasFamily() bool {
+	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 2, 1) > 0
+}
+
+func (p *GamePerson) Type() int {
+	return int(getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 3, 2))
+}
+
+type gamePersonJSON struct {
+	Name       string `json:"name"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Z          int    `json:"z"`
+	Gold       int    `json:"gold"`
+	Mana       int    `json:"mana"`
+	Health     int    `json:"health"`
+	Respect    int    `json:"respect"`
+	Strength   int    `json:"strength"`
+	Experience int    `json:"experience"`
+	Level      int    `json:"level"`
+	HasHouse   bool   `json:"hasHouse"`
+	HasGun     bool   `json:"hasGun"`
+	HasFamily  bool   `json:"hasFamily"`
+	PersonType int    `json:"type"`
+}
+
+func (p *GamePerson) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gamePersonJSON{
+		Name:       p.Name(),
+		X:          p.X(),
+		Y:          p.Y(),
+		Z:          p.Z(),
+		Gold:       p.Gold(),
+		Mana:       p.Mana(),
+		Health:     p.Health(),
+		Respect:    p.Respect(),
+		Strength:   p.Strength(),
+		Experience: p.Experience(),
+		Level:      p.Level(),
+		HasHouse:   p.HasHouse(),
+		HasGun:     p.HasGun(),
+		HasFamily:  p.HasFamily(),
+		PersonType: p.Type(),
+	})
+}
+
+// UnmarshalJSON validates fields with the same options as NewGamePerson
+func (p *GamePerson) UnmarshalJSON(data []byte) error {
+	var decoded gamePersonJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	options := []Option{
+		WithName(decoded.Name),
+		WithCoordinates(decoded.X, decoded.Y, decoded.Z),
+		WithGold(decoded.Gold),
+		WithMana(decoded.Mana),
+		WithHealth(decoded.Health),
+		WithRespect(decoded.Respect),
+		WithStrength(decoded.Strength),
+		WithExperience(decoded.Experience),
+		WithLevel(decoded.Level),
+		WithType(decoded.PersonType),
+	}
+
+	if decoded.HasHouse {
+		options = append(options, WithHouse())
+	}
+	if decoded.HasGun {
+		options = append(options, WithGun())
+	}
+	if decoded.HasFamily {
+		options = append(options, WithFamily())
+	}
+
+	person, err := NewGamePerson(options...)
+	if err != nil {
+		return err
+	}
+
+	*p = person
+	return nil
+}
+
+// MarshalBinary writes the packed layout as is: x, y, z, gold and the bit fields
+// as little endian integers followed by the name, level and flags bytes
+func (p *GamePerson) MarshalBinary() ([]byte, error) {
+	return p.appendBinary(make([]byte, 0, GamePersonSize)), nil
+}
+
+func (p *GamePerson) UnmarshalBinary(data []byte) error {
+	if len(data) != GamePersonSize {
+		return fmt.Errorf("%w: %d instead of %d bytes", ErrInvalidLength, len(data), GamePersonSize)
+	}
+
+	p.x = int32(binary.LittleEndian.Uint32(data[0:]))
+	p.y = int32(binary.LittleEndian.Uint32(data[4:]))
+	p.z = int32(binary.LittleEndian.Uint32(data[8:]))
+	p.gold = int32(binary.LittleEndian.Uint32(data[12:]))
+	p.manaHealthRespectStrengthExperience = binary.LittleEndian.Uint32(data[16:])
+	copy(p.nameLevelHouseGunFamilyType[:], data[20:])
+	return nil
+}
+
+func (p *GamePerson) appendBinary(data []byte) []byte {
+	data = binary.LittleEndian.AppendUint32(data, uint32(p.x))
+	data = binary.LittleEndian.AppendUint32(data, uint32(p.y))
+	data = binary.LittleEndian.AppendUint32(data, uint32(p.z))
+	data = binary.LittleEndian.AppendUint32(data, uint32(p.gold))
+	data = binary.LittleEndian.AppendUint32(data, p.manaHealthRespectStrengthExperience)
+	return append(data, p.nameLevelHouseGunFamilyType[:]...)
+}
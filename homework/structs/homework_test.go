@@ -3,238 +3,14 @@ package main
 import (
 	"encoding/json"
 	"math"
+	"strings"
 	"testing"
 	"unsafe"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-type Option func(*GamePerson)
-
-const (
-	ManaStart        = 0
-	ManaLength       = 10
-	HealthStart      = ManaStart + ManaLength
-	HealthLength     = 10
-	RespectStart     = HealthStart + HealthLength
-	RespectLength    = 4
-	StrengthStart    = RespectStart + RespectLength
-	StrengthLength   = 4
-	ExperienceStart  = StrengthStart + StrengthLength
-	ExperienceLength = 4
-	HouseStart       = 0
-	HouseLength      = 1
-	GunStart         = HouseStart + HouseLength
-	GunLength        = 1
-	FamilyStart      = GunStart + GunLength
-	FamilyLength     = 1
-	TypeStart        = FamilyStart + FamilyLength
-	TypeLength       = 2
-)
-
-func WithName(name string) func(*GamePerson) {
-	return func(person *GamePerson) {
-		copy(person.nameLevelHouseGunFamilyType[0:42], name)
-	}
-}
-
-func WithCoordinates(x, y, z int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.x = int32(x)
-		person.y = int32(y)
-		person.z = int32(z)
-	}
-}
-
-func WithGold(gold int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.gold = int32(gold)
-	}
-}
-
-func WithMana(mana int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(mana), ManaStart, ManaLength)
-	}
-}
-
-func WithHealth(health int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(health), HealthStart, HealthLength)
-	}
-}
-
-func WithRespect(respect int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(respect), RespectStart, RespectLength)
-	}
-}
-
-func WithStrength(strength int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(strength), StrengthStart, StrengthLength)
-	}
-}
-
-func WithExperience(experience int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.manaHealthRespectStrengthExperience = setBitsToInt(person.manaHealthRespectStrengthExperience, uint32(experience), ExperienceStart, ExperienceLength)
-	}
-}
-
-func WithLevel(level int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.nameLevelHouseGunFamilyType[42] = uint8(level)
-	}
-}
-
-func WithHouse() func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], 1, HouseStart, HouseLength)
-	}
-}
-
-func WithGun() func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], 1, GunStart, GunLength)
-	}
-}
-
-func WithFamily() func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], 1, FamilyStart, FamilyLength)
-	}
-}
-
-func WithType(personType int) func(*GamePerson) {
-	return func(person *GamePerson) {
-		person.nameLevelHouseGunFamilyType[43] = setBitsToInt(person.nameLevelHouseGunFamilyType[43], uint8(personType), TypeStart, TypeLength)
-	}
-}
-
-const (
-	BuilderGamePersonType = iota
-	BlacksmithGamePersonType
-	WarriorGamePersonType
-)
-
-type GamePerson struct {
-	x    int32
-	y    int32
-	z    int32
-	gold int32
-	// bits: 0..9 - mana 10..19 - health 20..23 - respect 24..27 - strength 28..31 - experience
-	manaHealthRespectStrengthExperience uint32
-	// bytes: 0..41 - name, 42 - level, 43: bits: 0 - house,1 - gun, 2 - family, 3..4 - type
-	nameLevelHouseGunFamilyType [44]uint8
-}
-
-func NewGamePerson(options ...Option) GamePerson {
-	person := GamePerson{}
-
-	for _, option := range options {
-		option(&person)
-	}
-
-	return person
-}
-
-func (p *GamePerson) Name() string {
-	return string(p.nameLevelHouseGunFamilyType[0:42])
-}
-
-func (p *GamePerson) X() int {
-	return int(p.x)
-}
-
-func (p *GamePerson) Y() int {
-	return int(p.y)
-}
-
-func (p *GamePerson) Z() int {
-	return int(p.z)
-}
-
-func (p *GamePerson) Gold() int {
-	return int(p.gold)
-}
-
-func (p *GamePerson) Mana() int {
-	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 0, 10))
-}
-
-func (p *GamePerson) Health() int {
-	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 10, 10))
-}
-
-func (p *GamePerson) Respect() int {
-	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 20, 4))
-}
-
-func (p *GamePerson) Strength() int {
-	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 24, 4))
-}
-
-func (p *GamePerson) Experience() int {
-	return int(getBitsFromInt(p.manaHealthRespectStrengthExperience, 28, 4))
-}
-
-func (p *GamePerson) Level() int {
-	return int(p.nameLevelHouseGunFamilyType[42])
-}
-
-func (p *GamePerson) HasHouse() bool {
-	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 0, 1) > 0
-}
-
-func (p *GamePerson) HasGun() bool {
-	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 1, 1) > 0
-}
-
-func (p *GamePerson) HasFamily() bool {
-	return getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 2, 1) > 0
-}
-
-func (p *GamePerson) Type() int {
-	return int(getBitsFromInt(p.nameLevelHouseGunFamilyType[43], 3, 2))
-}
-
-func (p *GamePerson) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Name       string `json:"name"`
-		X          int    `json:"x"`
-		Y          int    `json:"y"`
-		Z          int    `json:"z"`
-		Gold       int    `json:"gold"`
-		Mana       int    `json:"mana"`
-		Health     int    `json:"health"`
-		Respect    int    `json:"respect"`
-		Strength   int    `json:"strength"`
-		Experience int    `json:"experience"`
-		Level      int    `json:"level"`
-		HasHouse   bool   `json:"hasHouse"`
-		HasGun     bool   `json:"hasGun"`
-		HasFamily  bool   `json:"hasFamily"`
-		PersonType int    `json:"type"`
-	}{
-		Name:       p.Name(),
-		X:          p.X(),
-		Y:          p.Y(),
-		Z:          p.Z(),
-		Gold:       p.Gold(),
-		Mana:       p.Mana(),
-		Health:     p.Health(),
-		Respect:    p.Respect(),
-		Strength:   p.Strength(),
-		Experience: p.Experience(),
-		Level:      p.Level(),
-		HasHouse:   p.HasHouse(),
-		HasGun:     p.HasGun(),
-		HasFamily:  p.HasFamily(),
-		PersonType: p.Type(),
-	})
-}
-
 func TestGamePerson(t *testing.T) {
 	assert.LessOrEqual(t, unsafe.Sizeof(GamePerson{}), uintptr(64))
 
@@ -264,7 +40,8 @@ func TestGamePerson(t *testing.T) {
 		WithType(personType),
 	}
 
-	person := NewGamePerson(options...)
+	person, err := NewGamePerson(options...)
+	require.NoError(t, err)
 	assert.Equal(t, name, person.Name())
 	assert.Equal(t, x, person.X())
 	assert.Equal(t, y, person.Y())
@@ -284,4 +61,132 @@ func TestGamePerson(t *testing.T) {
 	expectedSserializedPerson := "{\"name\":\"aaaaaaaaaaaaa_bbbbbbbbbbbbb_cccccccccccccc\",\"x\":-2147483648,\"y\":2147483646,\"z\":0,\"gold\":2147483647,\"mana\":999,\"health\":1000,\"respect\":7,\"strength\":8,\"experience\":9,\"level\":10,\"hasHouse\":true,\"hasGun\":false,\"hasFamily\":true,\"type\":0}"
 	serializedPerson, _ := json.Marshal(&person)
 	assert.Equal(t, expectedSserializedPerson, string(serializedPerson))
+
+	var deserializedPerson GamePerson
+	require.NoError(t, json.Unmarshal(serializedPerson, &deserializedPerson))
+	assert.Equal(t, person, deserializedPerson)
+}
+
+func TestGamePersonValidation(t *testing.T) {
+	tests := map[string]struct {
+		option Option
+		err    error
+	}{
+		"name over 42 bytes":  {option: WithName(strings.Repeat("a", 43)), err: ErrNameTooLong},
+		"name with cut rune":  {option: WithName("имя\xd0"), err: ErrInvalidName},
+		"name with zero byte": {option: WithName("a\x00b"), err: ErrInvalidName},
+		"coordinate overflow": {option: WithCoordinates(0, math.MaxInt32+1, 0), err: ErrOutOfRange},
+		"gold overflow":       {option: WithGold(math.MinInt32 - 1), err: ErrOutOfRange},
+		"mana over 1023":      {option: WithMana(1024), err: ErrOutOfRange},
+		"negative health":     {option: WithHealth(-1), err: ErrOutOfRange},
+		"respect over 15":     {option: WithRespect(16), err: ErrOutOfRange},
+		"strength over 15":    {option: WithStrength(16), err: ErrOutOfRange},
+		"experience over 15":  {option: WithExperience(100), err: ErrOutOfRange},
+		"level over 255":      {option: WithLevel(256), err: ErrOutOfRange},
+		"unknown type":        {option: WithType(3), err: ErrOutOfRange},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewGamePerson(WithName("Ivan"), test.option)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	var person GamePerson
+	err := json.Unmarshal([]byte(`{"name":"Ivan","mana":2000}`), &person)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	assert.Equal(t, GamePerson{}, person)
+}
+
+func TestGamePersonName(t *testing.T) {
+	// 21 two bytes runes take the whole name
+	name := strings.Repeat("ж", 21)
+	person := newGamePerson(t, WithName(name))
+	assert.Equal(t, name, person.Name())
+
+	person = newGamePerson(t, WithName("Ivan"), WithName("Ян"))
+	assert.Equal(t, "Ян", person.Name())
+
+	// binary data may contain a rune cut by the name length
+	data, err := person.MarshalBinary()
+	require.NoError(t, err)
+	copy(data[20:], strings.Repeat("a", NameLength-1)+"\xd0")
+	require.NoError(t, person.UnmarshalBinary(data))
+	assert.Equal(t, strings.Repeat("a", NameLength-1), person.Name())
+}
+
+func newGamePerson(t *testing.T, options ...Option) GamePerson {
+	t.Helper()
+
+	person, err := NewGamePerson(options...)
+	require.NoError(t, err)
+	return person
+}
+
+func TestGamePersonBinary(t *testing.T) {
+	person := newGamePerson(t,
+		WithName("Ivan"),
+		WithCoordinates(-1, 2, math.MaxInt32),
+		WithGold(100),
+		WithMana(1000),
+		WithHealth(500),
+		WithExperience(15),
+		WithLevel(255),
+		WithGun(),
+		WithType(WarriorGamePersonType),
+	)
+
+	data, err := person.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, GamePersonSize)
+	assert.Equal(t, []byte{0xFF, 0xFF, 0xFF, 0xFF}, data[0:4])
+
+	var unmarshalled GamePerson
+	assert.NoError(t, unmarshalled.UnmarshalBinary(data))
+	assert.Equal(t, person, unmarshalled)
+
+	assert.ErrorIs(t, unmarshalled.UnmarshalBinary(data[:GamePersonSize-1]), ErrInvalidLength)
+}
+
+func TestPersonStore(t *testing.T) {
+	store := NewPersonStore(4)
+	store.Add(newGamePerson(t, WithName("builder"), WithGold(1000), WithType(BuilderGamePersonType)))
+	store.Add(newGamePerson(t, WithName("poor"), WithGold(10), WithType(WarriorGamePersonType)))
+	store.Add(newGamePerson(t, WithName("far"), WithGold(1000), WithCoordinates(100, 0, 0), WithType(WarriorGamePersonType)))
+	store.Add(newGamePerson(t, WithName("rich"), WithGold(1000), WithCoordinates(-5, 5, 0), WithHouse(), WithType(WarriorGamePersonType)))
+	assert.Equal(t, 4, store.Len())
+
+	query := Query{
+		Type:    WarriorGamePersonType,
+		MinGold: 100,
+		Box:     Box{MinX: -10, MinY: -10, MinZ: -10, MaxX: 10, MaxY: 10, MaxZ: 10},
+	}
+
+	indices := store.Select(query)
+	assert.Equal(t, []int{3}, indices)
+
+	rich := store.Get(3)
+	assert.Equal(t, "rich", rich.Name())
+	assert.Equal(t, -5, rich.X())
+	assert.True(t, rich.HasHouse())
+
+	// the last person takes the place of the removed one
+	store.Remove(1)
+	assert.Equal(t, 3, store.Len())
+	assert.Equal(t, []int{1}, store.Select(query))
+
+	snapshot, err := store.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, snapshot, 8+3*GamePersonSize)
+
+	var restored PersonStore
+	assert.NoError(t, restored.UnmarshalBinary(snapshot))
+	assert.Equal(t, store.Len(), restored.Len())
+	for index := range store.Len() {
+		assert.Equal(t, store.Get(index), restored.Get(index))
+	}
+
+	assert.ErrorIs(t, restored.UnmarshalBinary(snapshot[:len(snapshot)-1]), ErrInvalidLength)
+	assert.ErrorIs(t, restored.UnmarshalBinary(snapshot[:4]), ErrInvalidLength)
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Box is an inclusive bounding box
+type Box struct {
+	MinX, MinY, MinZ int
+	MaxX, MaxY, MaxZ int
+}
+
+func (b Box) contains(x, y, z int32) bool {
+	return int(x) >= b.MinX && int(x) <= b.MaxX &&
+		int(y) >= b.MinY && int(y) <= b.MaxY &&
+		int(z) >= b.MinZ && int(z) <= b.MaxZ
+}
+
+// Query selects persons of Type with more gold than MinGold inside Box
+type Query struct {
+	Type    int
+	MinGold int
+	Box     Box
+}
+
+// PersonStore keeps every field of persons in its own column,
+// so scans read only the columns used by a query
+type PersonStore struct {
+	xs     []int32
+	ys     []int32
+	zs     []int32
+	golds  []int32
+	stats  []uint32 // mana, health, respect, strength and experience bits
+	levels []uint8
+	flags  []uint8 // house, gun, family and type bits
+	names  [][NameLength]byte
+}
+
+func NewPersonStore(capacity int) *PersonStore {
+	return &PersonStore{
+		xs:     make([]int32, 0, capacity),
+		ys:     make([]int32, 0, capacity),
+		zs:     make([]int32, 0, capacity),
+		golds:  make([]int32, 0, capacity),
+		stats:  make([]uint32, 0, capacity),
+		levels: make([]uint8, 0, capacity),
+		flags:  make([]uint8, 0, capacity),
+		names:  make([][NameLength]byte, 0, capacity),
+	}
+}
+
+func (s *PersonStore) Len() int {
+	return len(s.xs)
+}
+
+// Add returns the index of the added person
+func (s *PersonStore) Add(person GamePerson) int {
+	s.xs = append(s.xs, person.x)
+	s.ys = append(s.ys, person.y)
+	s.zs = append(s.zs, person.z)
+	s.golds = append(s.golds, person.gold)
+	s.stats = append(s.stats, person.manaHealthRespectStrengthExperience)
+	s.levels = append(s.levels, person.nameLevelHouseGunFamilyType[42])
+	s.flags = append(s.flags, person.nameLevelHouseGunFamilyType[43])
+	s.names = append(s.names, [NameLength]byte(person.nameLevelHouseGunFamilyType[:NameLength]))
+	return len(s.xs) - 1
+}
+
+func (s *PersonStore) Get(index int) GamePerson {
+	person := GamePerson{
+		x:                                   s.xs[index],
+		y:                                   s.ys[index],
+		z:                                   s.zs[index],
+		gold:                                s.golds[index],
+		manaHealthRespectStrengthExperience: s.stats[index],
+	}
+
+	copy(person.nameLevelHouseGunFamilyType[:NameLength], s.names[index][:])
+	person.nameLevelHouseGunFamilyType[42] = s.levels[index]
+	person.nameLevelHouseGunFamilyType[43] = s.flags[index]
+	return person
+}
+
+func (s *PersonStore) Set(index int, person GamePerson) {
+	s.xs[index] = person.x
+	s.ys[index] = person.y
+	s.zs[index] = person.z
+	s.golds[index] = person.gold
+	s.stats[index] = person.manaHealthRespectStrengthExperience
+	s.levels[index] = person.nameLevelHouseGunFamilyType[42]
+	s.flags[index] = person.nameLevelHouseGunFamilyType[43]
+	s.names[index] = [NameLength]byte(person.nameLevelHouseGunFamilyType[:NameLength])
+}
+
+// Remove moves the last person to the index, so indices
+// of other persons stay valid except the last one
+func (s *PersonStore) Remove(index int) {
+	last := len(s.xs) - 1
+	if index != last {
+		s.Set(index, s.Get(last))
+	}
+
+	s.xs = s.xs[:last]
+	s.ys = s.ys[:last]
+	s.zs = s.zs[:last]
+	s.golds = s.golds[:last]
+	s.stats = s.stats[:last]
+	s.levels = s.levels[:last]
+	s.flags = s.flags[:last]
+	s.names = s.names[:last]
+}
+
+// Select returns indices of matching persons, the cheapest
+// columns are checked first and coordinates only for the rest
+func (s *PersonStore) Select(query Query) []int {
+	var indices []int
+	for index, flags := range s.flags {
+		if int(getBitsFromInt(flags, TypeStart, TypeLength)) != query.Type {
+			continue
+		}
+
+		if int(s.golds[index]) <= query.MinGold {
+			continue
+		}
+
+		if query.Box.contains(s.xs[index], s.ys[index], s.zs[index]) {
+			indices = append(indices, index)
+		}
+	}
+
+	return indices
+}
+
+// MarshalBinary writes a snapshot as the number of persons
+// followed by persons in their binary format
+func (s *PersonStore) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 8+s.Len()*GamePersonSize)
+	data = binary.LittleEndian.AppendUint64(data, uint64(s.Len()))
+	for index := range s.xs {
+		person := s.Get(index)
+		data = person.appendBinary(data)
+	}
+
+	return data, nil
+}
+
+func (s *PersonStore) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("%w: snapshot header is truncated", ErrInvalidLength)
+	}
+
+	count := binary.LittleEndian.Uint64(data)
+	data = data[8:]
+	if len(data)%GamePersonSize != 0 || uint64(len(data)/GamePersonSize) != count {
+		return fmt.Errorf("%w: %d bytes for %d persons", ErrInvalidLength, len(data), count)
+	}
+
+	*s = *NewPersonStore(int(count))
+	for len(data) > 0 {
+		var person GamePerson
+		if err := person.UnmarshalBinary(data[:GamePersonSize]); err != nil {
+			return err
+		}
+
+		s.Add(person)
+		data = data[GamePersonSize:]
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrWeightExceedsLimit = errors.New("semaphore: weight exceeds the limit")
+	ErrNegativeWeight     = errors.New("semaphore: negative weight")
+)
+
+type waiter struct {
+	weight int64
+	ready  chan struct{} // closed when the weight is acquired
+}
+
+// Weighted is a semaphore with weights, waiters are served in FIFO order: a small
+// request doesn't overtake a big one waiting before it, so big requests don't starve
+type Weighted struct {
+	mutex   sync.Mutex
+	limit   int64
+	used    int64
+	waiters list.List
+}
+
+func NewWeighted(limit int64) *Weighted {
+	return &Weighted{limit: limit}
+}
+
+// Acquire waits for the weight until the context is done,
+// nothing is acquired when an error is returned
+func (s *Weighted) Acquire(ctx context.Context, weight int64) error {
+	if weight < 0 {
+		return ErrNegativeWeight
+	}
+
+	s.mutex.Lock()
+	if weight > s.limit {
+		s.mutex.Unlock()
+		return ErrWeightExceedsLimit
+	}
+
+	if s.limit-s.used >= weight && s.waiters.Len() == 0 {
+		s.used += weight
+		s.mutex.Unlock()
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+
+	w := waiter{weight: weight, ready: make(chan struct{})}
+	element := s.waiters.PushBack(w)
+	s.mutex.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-w.ready:
+		// acquired together with the cancellation, the weight is given back
+		s.used -= weight
+	default:
+		isFront := s.waiters.Front() == element
+		s.waiters.Remove(element)
+		if !isFront {
+			return ctx.Err()
+		}
+	}
+
+	// waiters behind a removed front one may fit now
+	s.notifyWaiters()
+	return ctx.Err()
+}
+
+// TryAcquire doesn't wait, it fails when there are waiters even if the weight fits
+func (s *Weighted) TryAcquire(weight int64) bool {
+	if weight < 0 {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.limit-s.used >= weight && s.waiters.Len() == 0 {
+		s.used += weight
+		return true
+	}
+
+	return false
+}
+
+func (s *Weighted) Release(weight int64) {
+	if weight < 0 {
+		panic("semaphore: negative weight released")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.used -= weight
+	if s.used < 0 {
+		panic("semaphore: released more than held")
+	}
+
+	s.notifyWaiters()
+}
+
+// notifyWaiters wakes waiters from the front while they fit
+func (s *Weighted) notifyWaiters() {
+	for element := s.waiters.Front(); element != nil; element = s.waiters.Front() {
+		w := element.Value.(waiter)
+		if s.limit-s.used < w.weight {
+			return
+		}
+
+		s.used += w.weight
+		s.waiters.Remove(element)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,227 @@
+package semaphore
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -v ./...
+
+func TestAcquireRelease(t *testing.T) {
+	semaphore := NewWeighted(10)
+
+	require.NoError(t, semaphore.Acquire(context.Background(), 7))
+	assert.True(t, semaphore.TryAcquire(3))
+	assert.False(t, semaphore.TryAcquire(1))
+
+	semaphore.Release(5)
+	assert.True(t, semaphore.TryAcquire(5))
+	assert.False(t, semaphore.TryAcquire(1))
+
+	semaphore.Release(10)
+	assert.True(t, semaphore.TryAcquire(10))
+	semaphore.Release(10)
+
+	assert.ErrorIs(t, semaphore.Acquire(context.Background(), 11), ErrWeightExceedsLimit)
+	assert.PanicsWithValue(t, "semaphore: released more than held", func() { semaphore.Release(1) })
+}
+
+func TestNegativeWeight(t *testing.T) {
+	semaphore := NewWeighted(2)
+
+	// a negative weight would let the held weight exceed the limit later
+	assert.ErrorIs(t, semaphore.Acquire(context.Background(), -5), ErrNegativeWeight)
+	assert.False(t, semaphore.TryAcquire(-5))
+	assert.False(t, semaphore.TryAcquire(7))
+	assert.True(t, semaphore.TryAcquire(2))
+	assert.PanicsWithValue(t, "semaphore: negative weight released", func() { semaphore.Release(-1) })
+}
+
+func TestCancellation(t *testing.T) {
+	semaphore := NewWeighted(2)
+	require.NoError(t, semaphore.Acquire(context.Background(), 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, semaphore.Acquire(ctx, 1), context.DeadlineExceeded)
+
+	// nothing waits after the cancellation and nothing was acquired
+	semaphore.Release(2)
+	assert.True(t, semaphore.TryAcquire(2))
+	semaphore.Release(2)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, semaphore.Acquire(ctx, 1), "a free weight is acquired even with a done context")
+	assert.ErrorIs(t, semaphore.Acquire(ctx, 2), context.Canceled)
+}
+
+func TestCanceledFrontWaiter(t *testing.T) {
+	semaphore := NewWeighted(4)
+	require.NoError(t, semaphore.Acquire(context.Background(), 3))
+
+	// the big waiter blocks the small one behind it until it gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	big := make(chan error)
+	go func() {
+		big <- semaphore.Acquire(ctx, 4)
+	}()
+
+	waitForWaiters(t, semaphore, 1)
+
+	small := make(chan error)
+	go func() {
+		small <- semaphore.Acquire(context.Background(), 1)
+	}()
+
+	waitForWaiters(t, semaphore, 2)
+	assert.False(t, semaphore.TryAcquire(1))
+
+	cancel()
+	assert.ErrorIs(t, <-big, context.Canceled)
+	assert.NoError(t, <-small)
+}
+
+func TestFIFO(t *testing.T) {
+	semaphore := NewWeighted(3)
+	require.NoError(t, semaphore.Acquire(context.Background(), 1))
+
+	var order []int
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	weights := []int64{3, 1, 2, 1}
+	for index, weight := range weights {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, semaphore.Acquire(context.Background(), weight))
+
+			mutex.Lock()
+			order = append(order, index)
+			mutex.Unlock()
+
+			semaphore.Release(weight)
+		}()
+
+		waitForWaiters(t, semaphore, index+1)
+	}
+
+	semaphore.Release(1)
+	wg.Wait()
+
+	// the first waiter needs everything, it's served before the small ones behind it
+	assert.Equal(t, 0, order[0])
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, order)
+}
+
+func TestBigRequestIsNotStarved(t *testing.T) {
+	const limit = 10
+
+	semaphore := NewWeighted(limit)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// small requests keep the semaphore busy all the time
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				if semaphore.Acquire(ctx, 2) == nil {
+					time.Sleep(100 * time.Microsecond)
+					semaphore.Release(2)
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, semaphore.Acquire(context.Background(), limit))
+		semaphore.Release(limit)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the big request starved")
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStress(t *testing.T) {
+	const limit = 16
+	const workers = 64
+
+	semaphore := NewWeighted(limit)
+	var used atomic.Int64
+	var exceeded atomic.Bool
+
+	var wg sync.WaitGroup
+	for worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			random := rand.New(rand.NewSource(int64(worker)))
+			for range 500 {
+				weight := 1 + random.Int63n(limit)
+
+				ctx := context.Background()
+				var cancel context.CancelFunc = func() {}
+				if random.Intn(4) == 0 {
+					ctx, cancel = context.WithTimeout(ctx, time.Duration(random.Intn(50))*time.Microsecond)
+				}
+
+				acquired := semaphore.Acquire(ctx, weight) == nil
+				if !acquired && random.Intn(2) == 0 {
+					acquired = semaphore.TryAcquire(weight)
+				}
+
+				cancel()
+				if !acquired {
+					continue
+				}
+
+				if used.Add(weight) > limit {
+					exceeded.Store(true)
+				}
+
+				used.Add(-weight)
+				semaphore.Release(weight)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.False(t, exceeded.Load(), "the weight exceeded the limit")
+	assert.True(t, semaphore.TryAcquire(limit), "all weights are released")
+	assert.Zero(t, semaphore.waiters.Len())
+}
+
+func waitForWaiters(t *testing.T, semaphore *Weighted, count int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		semaphore.mutex.Lock()
+		defer semaphore.mutex.Unlock()
+
+		return semaphore.waiters.Len() == count
+	}, time.Second, time.Millisecond)
+}